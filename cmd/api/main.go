@@ -1,19 +1,32 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	stdhttp "net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"sre/internal/auth"
+	"sre/internal/flags"
 	"sre/internal/http"
 	httpclient "sre/internal/httpClient"
 	"sre/internal/integrations"
+	"sre/internal/metrics"
+	"sre/internal/storage"
 	"sre/internal/usecases"
 )
 
 func main() {
+	// Wrapping slog's default handler would deadlock once it becomes the
+	// default, so logs go through a text handler of their own.
+	slog.SetDefault(slog.New(auth.NewLogHandler(slog.NewTextHandler(os.Stderr, nil))))
+
 	backendURL := "http://localhost:8080"
 	if u := os.Getenv("BACKEND_URL"); u != "" {
 		backendURL = u
@@ -26,21 +39,145 @@ func main() {
 	if p := os.Getenv("PORT"); p != "" {
 		addr = ":" + p
 	}
+	dataDir := "./data"
+	if d := os.Getenv("SRE_DATA_DIR"); d != "" {
+		dataDir = d
+	}
+
+	runtimeFlags := flags.NewStore(flags.Defaults())
+	if path := os.Getenv("SRE_FLAGS_FILE"); path != "" {
+		if err := runtimeFlags.LoadFile(path); err != nil && !os.IsNotExist(err) {
+			panic(err)
+		}
+		go runtimeFlags.WatchFile(context.Background(), path, 2*time.Second)
+	}
 
+	registry := metrics.NewRegistry()
 	factory := httpclient.NewEndpointFactory(backendURL)
+	factory.RegisterMetrics(registry)
+	responseCache := httpclient.NewResponseCache(1024, 32<<20, 256<<20)
+	responseCache.RegisterMetrics(registry)
+	factory.Use(
+		responseCache.Middleware(),
+		httpclient.Retry(runtimeFlags),
+		httpclient.CircuitBreaker(runtimeFlags),
+		httpclient.Logging(),
+		httpclient.Metrics(registry),
+	)
 	searchEngine := integrations.NewSearchEngine(factory)
 	accountsAPI := integrations.NewAccountsApi(factory)
 	adjustmentFlow := integrations.NewAdjustmentFlowProcessor(factory)
 
-	searchSvc := usecases.NewSearchService(searchEngine)
-	accountSvc := usecases.NewAccountService(accountsAPI, accountsAPI, adjustmentFlow, myselfURL)
+	searchIndex := usecases.NewSearchIndex()
+	go searchIndex.RunRefresher(context.Background(), searchEngine, envDuration("SRE_SEARCH_INDEX_REFRESH", 30*time.Second))
+
+	searchSvc := usecases.NewSearchService(usecases.NewCachedAccountSearcher(searchEngine, runtimeFlags),
+		usecases.WithSearchIndex(searchIndex),
+	)
+	auditLog, err := storage.NewAuditLogFile(filepath.Join(dataDir, "audit.log"))
+	if err != nil {
+		panic(err)
+	}
+	feeLedger, err := storage.NewFeeLedgerStore(filepath.Join(dataDir, "fee-ledger"))
+	if err != nil {
+		panic(err)
+	}
+	scheduledStore, err := storage.NewScheduledAdjustmentStore(filepath.Join(dataDir, "scheduled-adjustments"))
+	if err != nil {
+		panic(err)
+	}
+	adjustmentStates, err := storage.NewAdjustmentStateStore(filepath.Join(dataDir, "adjustments"))
+	if err != nil {
+		panic(err)
+	}
+	sagaStore, err := storage.NewSagaStore(filepath.Join(dataDir, "sagas"))
+	if err != nil {
+		panic(err)
+	}
+	notificationStore, err := storage.NewNotificationStore(filepath.Join(dataDir, "notifications"))
+	if err != nil {
+		panic(err)
+	}
+	feeVersions, err := storage.NewFeeVersionStore(filepath.Join(dataDir, "fee-versions"))
+	if err != nil {
+		panic(err)
+	}
+	sagas := usecases.NewSagaOrchestrator(sagaStore)
+	scheduler := usecases.NewAdjustmentScheduler(scheduledStore)
+	bulkAdjuster := usecases.NewBulkAdjuster(searchSvc, usecases.BulkAdjustmentConfig{
+		Parallelism:  envInt("SRE_BULK_PARALLELISM", 8),
+		MaxItems:     envInt("SRE_BULK_MAX_ITEMS", 10000),
+		MaxPerClient: envInt("SRE_BULK_JOBS_PER_CLIENT", 2),
+		TTL:          envDuration("SRE_BULK_JOB_TTL", 24*time.Hour),
+	})
+	accountSvc := usecases.NewAccountService(accountsAPI, accountsAPI, adjustmentFlow, myselfURL,
+		usecases.WithRuntimeFlags(runtimeFlags),
+		usecases.WithFeeListeners(searchIndex),
+		usecases.WithAuditLog(auditLog),
+		usecases.WithFeeLedger(feeLedger),
+		usecases.WithAdjustmentStates(adjustmentStates),
+		usecases.WithSagas(sagas),
+		usecases.WithNotificationDedupe(notificationStore, feeVersions),
+		usecases.WithApprovalStatus(os.Getenv("SRE_APPROVAL_STATUS")),
+		usecases.WithScheduler(scheduler),
+		usecases.WithBulkAdjustments(bulkAdjuster),
+	)
+	go scheduler.Run(context.Background(), envDuration("SRE_SCHEDULER_INTERVAL", 10*time.Second))
+	go bulkAdjuster.Run(context.Background())
+	go sagas.Run(context.Background(), time.Hour, envDuration("SRE_SAGA_RETENTION", 7*24*time.Hour))
 	reportSvc := usecases.NewReportService(searchSvc)
 
+	snapshotStore, err := storage.NewReportSnapshotStore(filepath.Join(dataDir, "report-snapshots"))
+	if err != nil {
+		panic(err)
+	}
+	reportHistory := usecases.NewReportHistoryService(reportSvc, snapshotStore, usecases.SnapshotRetention{
+		MaxCount: envInt("SRE_REPORT_SNAPSHOT_KEEP", 96),
+		MaxAge:   envDuration("SRE_REPORT_SNAPSHOT_MAX_AGE", 7*24*time.Hour),
+	})
+	go reportHistory.RunSnapshots(context.Background(), envDuration("SRE_REPORT_SNAPSHOT_INTERVAL", 15*time.Minute))
+
+	jobResults, err := storage.NewReportResultStore(filepath.Join(dataDir, "report-jobs"))
+	if err != nil {
+		panic(err)
+	}
+	reportJobs := usecases.NewReportJobService(searchSvc, jobResults, usecases.ReportJobConfig{
+		Workers:      envInt("SRE_REPORT_JOB_WORKERS", 2),
+		QueueSize:    envInt("SRE_REPORT_JOB_QUEUE", 32),
+		MaxPerClient: envInt("SRE_REPORT_JOB_PER_CLIENT", 2),
+		TTL:          envDuration("SRE_REPORT_JOB_TTL", time.Hour),
+	})
+	go reportJobs.Run(context.Background())
+
 	r := chi.NewRouter()
+	shedder := http.NewLoadShedder(runtimeFlags, registry, map[string]http.Priority{
+		"GET /metrics":                     http.PriorityCritical,
+		"GET /v1/accounts/{id}":            http.PriorityCritical,
+		"POST /v1/accounts/notifications":  http.PriorityCritical,
+		"GET /v1/admin/flags":              http.PriorityCritical,
+		"PATCH /v1/admin/flags":            http.PriorityCritical,
+		"DELETE /v1/admin/flags":           http.PriorityCritical,
+		"GET /v1/report":                   http.PriorityLow,
+		"POST /v1/reports/snapshots":       http.PriorityLow,
+		"GET /v1/reports/jobs/{id}/result": http.PriorityLow,
+	})
+	r.Use(shedder.Middleware(r))
+	r.Handle("/metrics", registry.Handler())
+	authn := authenticator()
 	r.Route("/v1", func(r chi.Router) {
+		// The backend calls the notification callback without credentials.
+		r.Use(http.NewAccessControl(authn, routeScopes, "POST /v1/accounts/notifications").Middleware(r))
+		r.Use(http.NewRateLimiter(registry).Middleware(r))
 		http.NewAccountController(accountSvc).Routes(r)
+		http.NewTariffAdjustmentController(accountSvc, bulkAdjuster).Routes(r)
+		http.NewSagaController(sagas).Routes(r)
 		http.NewReportController(reportSvc).Routes(r)
+		http.NewReportHistoryController(reportHistory).Routes(r)
+		http.NewReportJobController(reportJobs).Routes(r)
 		http.NewSearchController(searchSvc).Routes(r)
+		http.NewSchemaController().Routes(r)
+		http.NewAdminController(runtimeFlags).Routes(r)
+		http.NewAuditController(usecases.NewAuditService(auditLog)).Routes(r)
 	})
 
 	fmt.Printf("SRE API listening on http://localhost%s (backend: %s)\n", addr, backendURL)
@@ -48,3 +185,123 @@ func main() {
 		panic(err)
 	}
 }
+
+// routeScopes is the scope each route requires. Routes not listed, like the
+// schemas, are open to any caller, anonymous included.
+var routeScopes = map[string]string{
+	"GET /v1/accounts/":                                    auth.ScopeAccountsRead,
+	"GET /v1/accounts/{id}":                                auth.ScopeAccountsRead,
+	"GET /v1/accounts/{id}/tariff-adjustments":             auth.ScopeAccountsRead,
+	"GET /v1/accounts/{id}/fee-history":                    auth.ScopeAccountsRead,
+	"GET /v1/accounts/{id}/scheduled-adjustments":          auth.ScopeAccountsRead,
+	"DELETE /v1/accounts/{id}/scheduled-adjustments/{sid}": auth.ScopeAdjustmentsWrite,
+	"POST /v1/accounts/{id}/tariff-adjustments":            auth.ScopeAdjustmentsWrite,
+	"GET /v1/tariff-adjustments/{transaction_id}":          auth.ScopeAccountsRead,
+	"DELETE /v1/tariff-adjustments/{transaction_id}":       auth.ScopeAdjustmentsWrite,
+	"POST /v1/tariff-adjustments/{transaction_id}/revert":  auth.ScopeAdjustmentsWrite,
+	"POST /v1/tariff-adjustments/bulk":                     auth.ScopeAdjustmentsWrite,
+	"GET /v1/tariff-adjustments/bulk/{id}":                 auth.ScopeAdjustmentsWrite,
+	"POST /v1/tariff-adjustments/bulk/{id}/retry":          auth.ScopeAdjustmentsWrite,
+	"GET /v1/sagas":                    auth.ScopeAccountsRead,
+	"GET /v1/sagas/{id}":               auth.ScopeAccountsRead,
+	"GET /v1/search":                   auth.ScopeAccountsRead,
+	"GET /v1/report":                   auth.ScopeReportsRead,
+	"GET /v1/reports/snapshots":        auth.ScopeReportsRead,
+	"POST /v1/reports/snapshots":       auth.ScopeReportsWrite,
+	"GET /v1/reports/diff":             auth.ScopeReportsRead,
+	"POST /v1/reports/jobs":            auth.ScopeReportsWrite,
+	"GET /v1/reports/jobs/{id}":        auth.ScopeReportsRead,
+	"DELETE /v1/reports/jobs/{id}":     auth.ScopeReportsWrite,
+	"GET /v1/reports/jobs/{id}/result": auth.ScopeReportsRead,
+	"GET /v1/admin/flags":              auth.ScopeAdmin,
+	"PATCH /v1/admin/flags":            auth.ScopeAdmin,
+	"DELETE /v1/admin/flags":           auth.ScopeAdmin,
+	"GET /v1/audit":                    auth.ScopeAdmin,
+}
+
+// authenticator builds the request authenticator from SRE_ADMIN_TOKEN,
+// SRE_API_KEYS_FILE and SRE_JWKS_FILE. With neither keys nor JWKS
+// configured, the API stays open: anonymous callers get every scope but admin.
+func authenticator() auth.Authenticator {
+	var chain auth.Chain
+	if token := os.Getenv("SRE_ADMIN_TOKEN"); token != "" {
+		chain.Authenticators = append(chain.Authenticators, auth.BearerToken{
+			Token:  token,
+			Client: auth.Client{Name: "admin", Scopes: []string{auth.ScopeAdmin}},
+		})
+	}
+	if path := os.Getenv("SRE_JWKS_FILE"); path != "" {
+		verifier, err := auth.LoadJWKSFile(path, auth.JWTOptions{
+			Issuer:    os.Getenv("SRE_JWT_ISSUER"),
+			Audience:  os.Getenv("SRE_JWT_AUDIENCE"),
+			Leeway:    envDuration("SRE_JWT_LEEWAY", 30*time.Second),
+			RateLimit: envRateLimit("SRE_JWT_RATE_LIMIT", auth.RateLimit{RPS: 10, Burst: 20}),
+		})
+		if err != nil {
+			panic(err)
+		}
+		chain.Authenticators = append(chain.Authenticators, verifier)
+	}
+	if path := os.Getenv("SRE_API_KEYS_FILE"); path != "" {
+		keys, err := auth.LoadKeyFile(path)
+		if err != nil {
+			panic(err)
+		}
+		chain.Authenticators = append(chain.Authenticators, keys)
+		if c, ok := keys.Anonymous(); ok {
+			chain.Anonymous = &c
+		}
+	}
+	if os.Getenv("SRE_JWKS_FILE") == "" && os.Getenv("SRE_API_KEYS_FILE") == "" {
+		slog.Warn("no SRE_API_KEYS_FILE or SRE_JWKS_FILE configured; the API is open to anonymous callers")
+		chain.Anonymous = &auth.Client{
+			Name:      "anonymous",
+			Anonymous: true,
+			Scopes: []string{
+				auth.ScopeAccountsRead, auth.ScopeAdjustmentsWrite,
+				auth.ScopeReportsRead, auth.ScopeReportsWrite,
+			},
+		}
+	}
+	return chain
+}
+
+// envDuration reads a time.Duration from the environment, or returns def.
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", key, err))
+	}
+	return d
+}
+
+// envRateLimit reads an auth.RateLimit written "<rps>/<burst>" from the
+// environment, or returns def.
+func envRateLimit(key string, def auth.RateLimit) auth.RateLimit {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	l, err := auth.ParseRateLimit(v)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", key, err))
+	}
+	return l
+}
+
+// envInt reads an int from the environment, or returns def.
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", key, err))
+	}
+	return n
+}
@@ -0,0 +1,28 @@
+// Command auditverify checks the hash chain of an audit log file and exits
+// with status 1 when a record was modified, removed or reordered.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sre/internal/storage"
+)
+
+func main() {
+	dataDir := "./data"
+	if d := os.Getenv("SRE_DATA_DIR"); d != "" {
+		dataDir = d
+	}
+	path := flag.String("file", filepath.Join(dataDir, "audit.log"), "audit log to verify")
+	flag.Parse()
+
+	n, err := storage.VerifyAuditFile(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v (%d records verified before it)\n", *path, err, n)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: OK, %d records\n", *path, n)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Scopes checked by the API routes.
+const (
+	ScopeAccountsRead     = "accounts:read"
+	ScopeAdjustmentsWrite = "adjustments:write"
+	ScopeReportsRead      = "reports:read"
+	ScopeReportsWrite     = "reports:write"
+	ScopeAdmin            = "admin"
+)
+
+var (
+	// ErrNoCredentials means the request carries no credentials the
+	// authenticator understands.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials means the request carries credentials that were
+	// rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator identifies the caller of a request. It returns
+// ErrNoCredentials when the request has none of the credentials it handles,
+// so another authenticator can try.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Client, error)
+}
+
+// Chain tries each authenticator in order. A request that none of them
+// recognizes is Anonymous when that is set, unless it carried credentials.
+type Chain struct {
+	Authenticators []Authenticator
+	Anonymous      *Client
+}
+
+func (c Chain) Authenticate(r *http.Request) (Client, error) {
+	for _, a := range c.Authenticators {
+		client, err := a.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return client, err
+		}
+	}
+	if r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key") != "" {
+		return Client{}, ErrInvalidCredentials
+	}
+	if c.Anonymous == nil {
+		return Client{}, ErrNoCredentials
+	}
+	return *c.Anonymous, nil
+}
+
+// BearerToken authenticates "Authorization: Bearer <token>" for one static
+// token as client. Other bearer tokens are left to the next authenticator.
+type BearerToken struct {
+	Token  string
+	Client Client
+}
+
+func (b BearerToken) Authenticate(r *http.Request) (Client, error) {
+	got, ok := bearer(r)
+	if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(b.Token)) != 1 {
+		return Client{}, ErrNoCredentials
+	}
+	return b.Client, nil
+}
+
+func bearer(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(h[7:]), true
+}
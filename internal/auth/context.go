@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+)
+
+type clientKey struct{}
+
+// WithClient returns a copy of ctx carrying c.
+func WithClient(ctx context.Context, c Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// ClientFrom returns the client carried by ctx.
+func ClientFrom(ctx context.Context) (Client, bool) {
+	c, ok := ctx.Value(clientKey{}).(Client)
+	return c, ok
+}
+
+// NewLogHandler wraps h so records logged with a context carrying a client
+// get a "client" attribute.
+func NewLogHandler(h slog.Handler) slog.Handler {
+	return logHandler{h}
+}
+
+type logHandler struct{ slog.Handler }
+
+func (h logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if c, ok := ClientFrom(ctx); ok {
+		r = r.Clone()
+		r.AddAttrs(slog.String("client", c.Name))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h logHandler) WithGroup(name string) slog.Handler {
+	return logHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWTOptions are the claims a JWTVerifier requires besides a valid signature.
+type JWTOptions struct {
+	// Issuer, when set, must equal the "iss" claim.
+	Issuer string
+	// Audience, when set, must be in the "aud" claim.
+	Audience string
+	// Leeway tolerates clock skew in "exp" and "nbf".
+	Leeway time.Duration
+	// RateLimit applies to each JWT client; JWTs carry no limits of their own.
+	RateLimit RateLimit
+}
+
+// JWTVerifier authenticates "Authorization: Bearer <jwt>" tokens signed with
+// RS256 or ES256 by a key of a local JWKS file.
+type JWTVerifier struct {
+	keys map[string]crypto.PublicKey
+	opts JWTOptions
+}
+
+// LoadJWKSFile reads the JSON Web Key Set at path. Only RSA and P-256 EC
+// signing keys are used; every key needs a "kid".
+func LoadJWKSFile(path string, opts JWTOptions) (*JWTVerifier, error) {
+	if err := opts.RateLimit.validate(); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	v := &JWTVerifier{keys: make(map[string]crypto.PublicKey), opts: opts}
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Kid == "" {
+			return nil, fmt.Errorf("%s: key %d has no kid", path, i)
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %s: %w", path, k.Kid, err)
+		}
+		v.keys[k.Kid] = pub
+	}
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("%s: no usable signing keys", path)
+	}
+	return v, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on P-256")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Authenticate verifies the bearer token. The client is named "jwt:" plus
+// the "client_id" claim, else "sub", so that it cannot share rate limit
+// buckets or report jobs with an API key client; its scopes come from
+// "scope" (space separated) or "scp".
+func (v *JWTVerifier) Authenticate(r *http.Request) (Client, error) {
+	token, ok := bearer(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		// Not a JWT; maybe another authenticator's bearer token.
+		return Client{}, ErrNoCredentials
+	}
+	claims, err := v.verify(token, time.Now())
+	if err != nil {
+		return Client{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	name := claims.ClientID
+	if name == "" {
+		name = claims.Subject
+	}
+	if name == "" {
+		return Client{}, fmt.Errorf("%w: token has neither client_id nor sub", ErrInvalidCredentials)
+	}
+	return Client{Name: "jwt:" + name, Scopes: claims.scopes(), RateLimit: v.opts.RateLimit}, nil
+}
+
+type jwtClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	ClientID  string          `json:"client_id"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       json.RawMessage `json:"scp"`
+}
+
+func (c jwtClaims) scopes() []string {
+	scopes := strings.Fields(c.Scope)
+	var list []string
+	if json.Unmarshal(c.Scp, &list) == nil {
+		scopes = append(scopes, list...)
+	}
+	var s string
+	if json.Unmarshal(c.Scp, &s) == nil {
+		scopes = append(scopes, strings.Fields(s)...)
+	}
+	return scopes
+}
+
+func (c jwtClaims) audiences() []string {
+	var list []string
+	if json.Unmarshal(c.Audience, &list) == nil {
+		return list
+	}
+	var s string
+	if json.Unmarshal(c.Audience, &s) == nil {
+		return []string{s}
+	}
+	return nil
+}
+
+func (v *JWTVerifier) verify(token string, now time.Time) (jwtClaims, error) {
+	var claims jwtClaims
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims, fmt.Errorf("header: %w", err)
+	}
+	key, ok := v.keys[header.Kid]
+	if !ok {
+		return claims, fmt.Errorf("unknown key id %q", header.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errors.New("signature is not base64url")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return claims, fmt.Errorf("algorithm %q does not match RSA key", header.Alg)
+		}
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) != nil {
+			return claims, errors.New("bad signature")
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" {
+			return claims, fmt.Errorf("algorithm %q does not match EC key", header.Alg)
+		}
+		if len(sig) != 64 {
+			return claims, errors.New("bad signature")
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return claims, errors.New("bad signature")
+		}
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, fmt.Errorf("claims: %w", err)
+	}
+	if claims.ExpiresAt == nil {
+		return claims, errors.New("token has no exp")
+	}
+	if now.After(unixTime(*claims.ExpiresAt).Add(v.opts.Leeway)) {
+		return claims, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(v.opts.Leeway).Before(unixTime(*claims.NotBefore)) {
+		return claims, errors.New("token not valid yet")
+	}
+	if v.opts.Issuer != "" && claims.Issuer != v.opts.Issuer {
+		return claims, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if v.opts.Audience != "" && !slices.Contains(claims.audiences(), v.opts.Audience) {
+		return claims, errors.New("token is not for this audience")
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return errors.New("not base64url")
+	}
+	return json.Unmarshal(b, v)
+}
+
+func unixTime(sec float64) time.Time {
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
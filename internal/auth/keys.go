@@ -0,0 +1,197 @@
+// Package auth authenticates API clients and carries their identity through
+// request contexts.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RateLimit is a token bucket: RPS tokens are added per second up to Burst.
+// The zero value means unlimited.
+type RateLimit struct {
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst"`
+}
+
+// Unlimited reports whether l imposes no limit.
+func (l RateLimit) Unlimited() bool { return l.RPS == 0 && l.Burst == 0 }
+
+func (l RateLimit) validate() error {
+	if l.Unlimited() {
+		return nil
+	}
+	if l.RPS <= 0 || l.Burst < 1 {
+		return fmt.Errorf("rate limit needs rps > 0 and burst >= 1, got rps=%g burst=%d", l.RPS, l.Burst)
+	}
+	return nil
+}
+
+// ParseRateLimit reads a limit written "<rps>/<burst>", like "5/10"; an
+// empty string is unlimited.
+func ParseRateLimit(s string) (RateLimit, error) {
+	var l RateLimit
+	if s == "" {
+		return l, nil
+	}
+	rps, burst, ok := strings.Cut(s, "/")
+	if !ok {
+		return l, fmt.Errorf("rate limit %q is not <rps>/<burst>", s)
+	}
+	var err error
+	if l.RPS, err = strconv.ParseFloat(rps, 64); err != nil {
+		return l, fmt.Errorf("rate limit %q: %w", s, err)
+	}
+	if l.Burst, err = strconv.Atoi(burst); err != nil {
+		return l, fmt.Errorf("rate limit %q: %w", s, err)
+	}
+	return l, l.validate()
+}
+
+// Client is an authenticated API caller.
+type Client struct {
+	Name   string
+	Scopes []string
+	// RateLimit applies to all of the client's requests together.
+	RateLimit RateLimit
+	// RouteLimits applies per route, keyed like "GET /v1/report".
+	RouteLimits map[string]RateLimit
+	// Anonymous is set for callers without credentials.
+	Anonymous bool
+}
+
+// HasScope reports whether c was granted scope.
+func (c Client) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// KeyRegistry maps API keys to clients.
+type KeyRegistry struct {
+	byHash    map[[sha256.Size]byte]Client
+	anonymous *Client
+}
+
+// keyFile is the format of SRE_API_KEYS_FILE.
+type keyFile struct {
+	Keys      []keyEntry `json:"keys"`
+	Anonymous *keyEntry  `json:"anonymous"`
+}
+
+type keyEntry struct {
+	Client string `json:"client"`
+	// Key is the key in clear text; prefer KeySHA256, its hex SHA-256, so the
+	// file does not hold usable secrets.
+	Key       string               `json:"key"`
+	KeySHA256 string               `json:"key_sha256"`
+	Scopes    []string             `json:"scopes"`
+	RateLimit RateLimit            `json:"rate_limit"`
+	Routes    map[string]RateLimit `json:"routes"`
+}
+
+func (e keyEntry) client() (Client, error) {
+	if e.Client == "" {
+		return Client{}, errors.New("client name is required")
+	}
+	if err := e.RateLimit.validate(); err != nil {
+		return Client{}, fmt.Errorf("client %s: %w", e.Client, err)
+	}
+	for route, l := range e.Routes {
+		if err := l.validate(); err != nil {
+			return Client{}, fmt.Errorf("client %s, route %s: %w", e.Client, route, err)
+		}
+	}
+	return Client{Name: e.Client, Scopes: e.Scopes, RateLimit: e.RateLimit, RouteLimits: e.Routes}, nil
+}
+
+// LoadKeyFile reads a key registry from a JSON file like:
+//
+//	{
+//	  "keys": [{
+//	    "client": "finance",
+//	    "key_sha256": "9f86d08...",
+//	    "scopes": ["reports:read"],
+//	    "rate_limit": {"rps": 5, "burst": 10},
+//	    "routes": {"GET /v1/report": {"rps": 0.5, "burst": 2}}
+//	  }],
+//	  "anonymous": {"client": "anonymous", "rate_limit": {"rps": 1, "burst": 5}}
+//	}
+//
+// Without "anonymous", requests must present a key.
+func LoadKeyFile(path string) (*KeyRegistry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f keyFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	reg := &KeyRegistry{byHash: make(map[[sha256.Size]byte]Client, len(f.Keys))}
+	for i, e := range f.Keys {
+		c, err := e.client()
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %d: %w", path, i, err)
+		}
+		var sum [sha256.Size]byte
+		switch {
+		case e.Key != "" && e.KeySHA256 == "":
+			sum = sha256.Sum256([]byte(e.Key))
+		case e.Key == "" && e.KeySHA256 != "":
+			h, err := hex.DecodeString(e.KeySHA256)
+			if err != nil || len(h) != sha256.Size {
+				return nil, fmt.Errorf("%s: key %d: key_sha256 must be 64 hex digits", path, i)
+			}
+			copy(sum[:], h)
+		default:
+			return nil, fmt.Errorf("%s: key %d: set exactly one of key and key_sha256", path, i)
+		}
+		if _, dup := reg.byHash[sum]; dup {
+			return nil, fmt.Errorf("%s: key %d: duplicate key", path, i)
+		}
+		reg.byHash[sum] = c
+	}
+	if f.Anonymous != nil {
+		c, err := f.Anonymous.client()
+		if err != nil {
+			return nil, fmt.Errorf("%s: anonymous: %w", path, err)
+		}
+		c.Anonymous = true
+		reg.anonymous = &c
+	}
+	return reg, nil
+}
+
+// Authenticate identifies the caller by its X-API-Key header.
+func (r *KeyRegistry) Authenticate(req *http.Request) (Client, error) {
+	key := strings.TrimSpace(req.Header.Get("X-API-Key"))
+	if key == "" {
+		return Client{}, ErrNoCredentials
+	}
+	c, ok := r.Lookup(key)
+	if !ok {
+		return Client{}, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	return c, nil
+}
+
+// Lookup returns the client owning key.
+func (r *KeyRegistry) Lookup(key string) (Client, bool) {
+	c, ok := r.byHash[sha256.Sum256([]byte(key))]
+	return c, ok
+}
+
+// Anonymous returns the client used for requests without a key, if allowed.
+func (r *KeyRegistry) Anonymous() (Client, bool) {
+	if r.anonymous == nil {
+		return Client{}, false
+	}
+	return *r.anonymous, true
+}
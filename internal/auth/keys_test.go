@@ -0,0 +1,31 @@
+package auth
+
+import "testing"
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RateLimit
+		wantErr bool
+	}{
+		{"", RateLimit{}, false},
+		{"5/10", RateLimit{RPS: 5, Burst: 10}, false},
+		{"0.5/1", RateLimit{RPS: 0.5, Burst: 1}, false},
+		{"0/0", RateLimit{}, false},
+		{"5", RateLimit{}, true},
+		{"x/10", RateLimit{}, true},
+		{"5/1.5", RateLimit{}, true},
+		{"5/0", RateLimit{}, true},
+		{"-1/3", RateLimit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRateLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRateLimit(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseRateLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,15 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidAdjustment is returned for tariff adjustments that must not be
+// sent.
+var ErrInvalidAdjustment = errors.New("invalid tariff adjustment")
+
 // Account represents a financial account (checking, loan, card, etc.).
 type Account struct {
 	ID         string  `json:"id"`
@@ -14,4 +24,22 @@ type TariffAdjustmentRequest struct {
 	AccountID     string  `json:"account_id"`
 	NewFee        float64 `json:"new_fee"`
 	Status        string  `json:"status"`
+	// RequestedBy names the client that requested the adjustment.
+	RequestedBy string `json:"requested_by,omitempty"`
+	// RevertOf is the transaction this adjustment compensates, if any.
+	RevertOf string `json:"revert_of,omitempty"`
+}
+
+// Validate checks r before it is sent, scheduled or previewed, so that every
+// path accepts the same adjustments.
+func (r TariffAdjustmentRequest) Validate() error {
+	switch {
+	case r.AccountID == "":
+		return fmt.Errorf("%w: account_id is required", ErrInvalidAdjustment)
+	case math.IsNaN(r.NewFee) || math.IsInf(r.NewFee, 0):
+		return fmt.Errorf("%w: new_fee must be a finite number", ErrInvalidAdjustment)
+	case r.NewFee < 0:
+		return fmt.Errorf("%w: new_fee is negative", ErrInvalidAdjustment)
+	}
+	return nil
 }
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTariffAdjustmentRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   TariffAdjustmentRequest
+		valid bool
+	}{
+		{"valid", TariffAdjustmentRequest{AccountID: "acc-1", NewFee: 12.5}, true},
+		{"zero fee", TariffAdjustmentRequest{AccountID: "acc-1"}, true},
+		{"no account", TariffAdjustmentRequest{NewFee: 1}, false},
+		{"negative fee", TariffAdjustmentRequest{AccountID: "acc-1", NewFee: -0.01}, false},
+		{"NaN fee", TariffAdjustmentRequest{AccountID: "acc-1", NewFee: math.NaN()}, false},
+		{"infinite fee", TariffAdjustmentRequest{AccountID: "acc-1", NewFee: math.Inf(1)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidAdjustment) {
+				t.Errorf("Validate() = %v, want ErrInvalidAdjustment", err)
+			}
+		})
+	}
+}
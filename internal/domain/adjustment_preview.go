@@ -0,0 +1,30 @@
+package domain
+
+// AdjustmentPreview is what a set of tariff adjustments would do, computed
+// without sending them. Valid is false when any item has errors.
+type AdjustmentPreview struct {
+	Valid bool          `json:"valid"`
+	Items []PreviewItem `json:"items"`
+	// Current and Projected are the report before and after the adjustments.
+	Current   Report `json:"current"`
+	Projected Report `json:"projected"`
+	// EnteredTop, LeftTop and FeeChanges compare the rankings of Current and
+	// Projected: the custom Top when requested, else Top100ByFee.
+	EnteredTop []RankedAccount `json:"entered_top"`
+	LeftTop    []RankedAccount `json:"left_top"`
+	FeeChanges []TopFeeChange  `json:"fee_changes"`
+}
+
+// PreviewItem is one previewed adjustment. Errors would make it fail;
+// Warnings flag adjustments worth a second look.
+type PreviewItem struct {
+	Index     int      `json:"index"`
+	AccountID string   `json:"account_id"`
+	Name      string   `json:"name,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	OldFee    *float64 `json:"old_fee,omitempty"`
+	NewFee    float64  `json:"new_fee"`
+	Delta     *float64 `json:"delta,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
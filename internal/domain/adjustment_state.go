@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotCancellable is returned when cancelling a tariff adjustment that
+	// was already approved, applied or cancelled.
+	ErrNotCancellable = errors.New("tariff adjustment can no longer be cancelled")
+	// ErrNotRevertible is returned when reverting a tariff adjustment that was
+	// not applied, was already reverted, was followed by another applied
+	// adjustment, or whose prior fee is unknown.
+	ErrNotRevertible = errors.New("tariff adjustment cannot be reverted")
+)
+
+// AdjustmentState is where a tariff adjustment sent through this API stands
+// in its approval flow. Status is one of FeeRequested, FeeCancelled,
+// FeeApproved and FeeApplied.
+type AdjustmentState struct {
+	TransactionID string `json:"transaction_id"`
+	AccountID     string `json:"account_id"`
+	// OldFee is the fee before the adjustment: read when it was requested,
+	// then when it was applied. It is unset when it could not be read.
+	OldFee      *float64  `json:"old_fee,omitempty"`
+	NewFee      float64   `json:"new_fee"`
+	Status      string    `json:"status"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	RevertOf    string    `json:"revert_of,omitempty"`
+	RevertedBy  string    `json:"reverted_by,omitempty"`
+	// Voided marks an adjustment cancelled by its failed saga rather than by
+	// a client; retrying it reopens it.
+	Voided bool `json:"voided,omitempty"`
+}
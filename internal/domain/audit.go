@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Audit actions, one per fee-changing operation.
+const (
+	// AuditAdjustmentRequested records a tariff adjustment sent to the backend.
+	AuditAdjustmentRequested = "adjustment_requested"
+	// AuditAdjustmentScheduled records a tariff adjustment held for a later
+	// effective time.
+	AuditAdjustmentScheduled = "adjustment_scheduled"
+	// AuditScheduleCancelled records a scheduled adjustment cancelled before
+	// it was sent.
+	AuditScheduleCancelled = "schedule_cancelled"
+	// AuditAdjustmentNotified records an approval notification from the backend.
+	AuditAdjustmentNotified = "adjustment_notified"
+	// AuditFeeUpdated records a fee about to be written to an account.
+	AuditFeeUpdated = "fee_updated"
+	// AuditFeeUpdateFailed records a fee update that could not be written
+	// after its AuditFeeUpdated record; the account kept its fee.
+	AuditFeeUpdateFailed = "fee_update_failed"
+	// AuditAdjustmentCancelled records a tariff adjustment cancelled before
+	// its approval.
+	AuditAdjustmentCancelled = "adjustment_cancelled"
+)
+
+var (
+	// ErrAuditChainBroken is returned when an audit record does not follow
+	// the one before it.
+	ErrAuditChainBroken = errors.New("audit chain broken")
+	// ErrInvalidAuditQuery is returned for audit queries with bad filters.
+	ErrInvalidAuditQuery = errors.New("invalid audit query")
+)
+
+// FeeNotification is the backend's notice that a tariff adjustment moved on
+// in its approval flow.
+type FeeNotification struct {
+	TransactionID string `json:"transaction_id"`
+	AccountID     string `json:"account_id"`
+	Status        string `json:"status"`
+}
+
+// AuditRecord is an entry of the append-only audit log. Hash covers every
+// other field, PrevHash included, so changing, removing or reordering
+// records breaks the chain.
+type AuditRecord struct {
+	Seq           int64     `json:"seq"`
+	Time          time.Time `json:"time"`
+	Action        string    `json:"action"`
+	Principal     string    `json:"principal"`
+	AccountID     string    `json:"account_id"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	// OldFee is unset when the fee could not be read.
+	OldFee *float64 `json:"old_fee,omitempty"`
+	NewFee *float64 `json:"new_fee,omitempty"`
+	Status string   `json:"status,omitempty"`
+	// EffectiveAt is when a scheduled adjustment takes effect.
+	EffectiveAt *time.Time `json:"effective_at,omitempty"`
+	// RevertOf is the transaction a compensating adjustment reverts.
+	RevertOf string `json:"revert_of,omitempty"`
+	// PrevHash is the Hash of the previous record, empty for the first.
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// ComputeHash returns the hex SHA-256 of r without its Hash field.
+func (r AuditRecord) ComputeHash() string {
+	r.Hash = ""
+	b, err := json.Marshal(r)
+	if err != nil {
+		// A struct of strings, numbers and a time always marshals.
+		panic(err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Follows checks that r is the valid successor of prev, or a valid first
+// record when prev is nil.
+func (r AuditRecord) Follows(prev *AuditRecord) error {
+	wantSeq, wantPrev := int64(1), ""
+	if prev != nil {
+		wantSeq, wantPrev = prev.Seq+1, prev.Hash
+	}
+	switch {
+	case r.Seq != wantSeq:
+		return fmt.Errorf("%w: record %d follows record %d", ErrAuditChainBroken, r.Seq, wantSeq-1)
+	case r.PrevHash != wantPrev:
+		return fmt.Errorf("%w: record %d does not link to the previous hash", ErrAuditChainBroken, r.Seq)
+	case r.Hash != r.ComputeHash():
+		return fmt.Errorf("%w: record %d was modified", ErrAuditChainBroken, r.Seq)
+	}
+	return nil
+}
+
+// AuditQuery filters audit records. Zero fields do not filter.
+type AuditQuery struct {
+	AccountID string
+	// From and To bound Time, inclusive and exclusive.
+	From, To time.Time
+	// AfterSeq skips records up to this sequence number, for paging.
+	AfterSeq int64
+	Limit    int
+}
+
+// Matches reports whether r passes the filters of q, ignoring Limit.
+func (q AuditQuery) Matches(r AuditRecord) bool {
+	return r.Seq > q.AfterSeq &&
+		(q.AccountID == "" || r.AccountID == q.AccountID) &&
+		(q.From.IsZero() || !r.Time.Before(q.From)) &&
+		(q.To.IsZero() || r.Time.Before(q.To))
+}
+
+// AuditPage is a page of audit records. NextAfter, when set, is the AfterSeq
+// that fetches the next page.
+type AuditPage struct {
+	Records   []AuditRecord `json:"records"`
+	NextAfter int64         `json:"next_after,omitempty"`
+}
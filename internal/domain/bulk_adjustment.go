@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Bulk adjustment job statuses; running is the only one that is not final.
+const (
+	BulkRunning         = "running"
+	BulkSucceeded       = "succeeded"
+	BulkPartiallyFailed = "partially_failed"
+	BulkFailed          = "failed"
+)
+
+// Bulk adjustment item statuses.
+const (
+	BulkItemPending   = "pending"
+	BulkItemSucceeded = "succeeded"
+	BulkItemFailed    = "failed"
+)
+
+var (
+	// ErrInvalidBulkRequest is returned for bulk adjustment requests that
+	// cannot be run.
+	ErrInvalidBulkRequest = errors.New("invalid bulk adjustment request")
+	// ErrBulkJobRunning is returned when retrying a job that is still running.
+	ErrBulkJobRunning = errors.New("bulk adjustment job is still running")
+)
+
+// BulkAdjustmentRequest asks for many tariff adjustments at once: either
+// Items, or the accounts matching Rule.
+type BulkAdjustmentRequest struct {
+	Items []BulkAdjustmentInput `json:"items,omitempty"`
+	Rule  *BulkAdjustmentRule   `json:"rule,omitempty"`
+}
+
+// BulkAdjustmentInput is one adjustment of a bulk request.
+type BulkAdjustmentInput struct {
+	AccountID string  `json:"account_id"`
+	NewFee    float64 `json:"new_fee"`
+}
+
+// BulkAdjustmentRule reprices every account matching Query, written in the
+// search query language (e.g. "type:checking"). Exactly one of Percent,
+// Delta and Fee says how.
+type BulkAdjustmentRule struct {
+	Query string `json:"query"`
+	// Percent changes the fee by this percentage, e.g. 5 for +5%.
+	Percent *float64 `json:"percent,omitempty"`
+	// Delta adds this amount to the fee.
+	Delta *float64 `json:"delta,omitempty"`
+	// Fee replaces the fee.
+	Fee *float64 `json:"fee,omitempty"`
+}
+
+// Validate checks that r says how to change fees.
+func (r BulkAdjustmentRule) Validate() error {
+	n := 0
+	for _, v := range []*float64{r.Percent, r.Delta, r.Fee} {
+		if v != nil {
+			n++
+		}
+	}
+	if n != 1 {
+		return errors.New("rule needs exactly one of percent, delta and fee")
+	}
+	return nil
+}
+
+// Apply returns the fee that replaces fee, rounded to cents.
+func (r BulkAdjustmentRule) Apply(fee float64) float64 {
+	switch {
+	case r.Percent != nil:
+		fee *= 1 + *r.Percent/100
+	case r.Delta != nil:
+		fee += *r.Delta
+	case r.Fee != nil:
+		fee = *r.Fee
+	}
+	return math.Round(fee*100) / 100
+}
+
+// BulkAdjustmentJob tracks a bulk request. Items of one account are sent
+// one at a time, in request order.
+type BulkAdjustmentJob struct {
+	ID       string `json:"id"`
+	ClientID string `json:"client_id"`
+	// Quota is the Quota of the job's owner.
+	Quota  string `json:"-"`
+	Status string `json:"status"`
+	// Rule is the rule the items were expanded from, if any.
+	Rule      *BulkAdjustmentRule  `json:"rule,omitempty"`
+	Total     int                  `json:"total"`
+	Pending   int                  `json:"pending"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Items     []BulkAdjustmentItem `json:"items"`
+	CreatedAt time.Time            `json:"created_at"`
+	// FinishedAt is when the last run of the job ended; a retry clears it.
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// ExpiresAt is when a finished job is discarded.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BulkAdjustmentItem is the outcome of one adjustment of a bulk job.
+// Retries reuse TransactionID, so the backend deduplicates them.
+type BulkAdjustmentItem struct {
+	Index         int      `json:"index"`
+	AccountID     string   `json:"account_id"`
+	TransactionID string   `json:"transaction_id"`
+	OldFee        *float64 `json:"old_fee,omitempty"`
+	NewFee        float64  `json:"new_fee"`
+	Status        string   `json:"status"`
+	Attempts      int      `json:"attempts"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// Request is the adjustment it sends.
+func (it BulkAdjustmentItem) Request() TariffAdjustmentRequest {
+	return TariffAdjustmentRequest{TransactionID: it.TransactionID, AccountID: it.AccountID, NewFee: it.NewFee}
+}
+
+// Finished reports whether j reached a final status.
+func (j BulkAdjustmentJob) Finished() bool { return j.Status != BulkRunning }
@@ -0,0 +1,10 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrNotFound is returned by local stores when the requested item does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrBackendCall wraps the failure of a backend call an operation needed.
+	ErrBackendCall = errors.New("backend call failed")
+)
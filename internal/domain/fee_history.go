@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Fee history entry statuses, in lifecycle order.
+const (
+	// FeeRecorded marks an adjustment known only from the backend history.
+	FeeRecorded = "recorded"
+	// FeeScheduled marks an adjustment waiting for its effective time.
+	FeeScheduled = "scheduled"
+	// FeeCancelled marks an adjustment cancelled before it was sent or
+	// approved.
+	FeeCancelled = "cancelled"
+	// FeeRequested marks an adjustment sent to the backend.
+	FeeRequested = "requested"
+	// FeeApproved marks an adjustment whose approval was notified.
+	FeeApproved = "approved"
+	// FeeApplied marks an adjustment whose fee was written to the account.
+	FeeApplied = "applied"
+)
+
+// Fee history entry sources.
+const (
+	// FeeSourceLedger marks entries tracked by the local ledger.
+	FeeSourceLedger = "ledger"
+	// FeeSourceBackend marks entries known only from the backend history.
+	FeeSourceBackend = "backend"
+)
+
+// ErrInvalidFeeHistoryQuery is returned for fee history queries with bad
+// filters.
+var ErrInvalidFeeHistoryQuery = errors.New("invalid fee history query")
+
+// FeeHistoryEntry is a tariff adjustment of an account as it went through
+// the approval flow. Timestamps and OldFee are unset when unknown.
+type FeeHistoryEntry struct {
+	TransactionID string     `json:"transaction_id"`
+	AccountID     string     `json:"account_id"`
+	OldFee        *float64   `json:"old_fee,omitempty"`
+	NewFee        float64    `json:"new_fee"`
+	Status        string     `json:"status"`
+	RequestedBy   string     `json:"requested_by,omitempty"`
+	RequestedAt   *time.Time `json:"requested_at,omitempty"`
+	EffectiveAt   *time.Time `json:"effective_at,omitempty"`
+	ApprovedAt    *time.Time `json:"approved_at,omitempty"`
+	AppliedAt     *time.Time `json:"applied_at,omitempty"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+	// RevertOf links a compensating adjustment to the one it reverts, and
+	// RevertedBy the other way round.
+	RevertOf   string `json:"revert_of,omitempty"`
+	RevertedBy string `json:"reverted_by,omitempty"`
+	Source     string `json:"source"`
+}
+
+// FeeHistoryQuery selects a page of an account's fee history.
+type FeeHistoryQuery struct {
+	AccountID string
+	// From and To bound RequestedAt, inclusive and exclusive. Entries
+	// without RequestedAt never match a time bound.
+	From, To time.Time
+	Offset   int
+	Limit    int
+}
+
+// Matches reports whether e is within the time bounds of q.
+func (q FeeHistoryQuery) Matches(e FeeHistoryEntry) bool {
+	if q.From.IsZero() && q.To.IsZero() {
+		return true
+	}
+	return e.RequestedAt != nil &&
+		(q.From.IsZero() || !e.RequestedAt.Before(q.From)) &&
+		(q.To.IsZero() || e.RequestedAt.Before(q.To))
+}
+
+// FeeHistoryPage is a page of fee history, oldest first. Total counts the
+// entries matching the query across all pages; NextOffset, when set, fetches
+// the next page. Partial is set when the backend history could not be read,
+// so only the ledger is shown.
+type FeeHistoryPage struct {
+	Entries    []FeeHistoryEntry `json:"entries"`
+	Total      int               `json:"total"`
+	NextOffset int               `json:"next_offset,omitempty"`
+	Partial    bool              `json:"partial,omitempty"`
+}
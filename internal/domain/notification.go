@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"cmp"
+	"time"
+)
+
+// NotificationStatusApproved is the default status of the notification that
+// approves an adjustment.
+const NotificationStatusApproved = "approved"
+
+// Decisions taken on approval notifications.
+const (
+	// NotificationApplied marks a notification whose adjustment was applied.
+	NotificationApplied = "applied"
+	// NotificationStale marks a notification of an adjustment older than, or
+	// the same as, the one the account already has.
+	NotificationStale = "stale"
+	// NotificationIgnored marks a notification of a cancelled adjustment, or
+	// one whose status is not an approval.
+	NotificationIgnored = "ignored"
+)
+
+// ProcessedNotification is the decision taken on the first delivery of a
+// notification of a transaction. Later deliveries only count in Deliveries,
+// except that an approval is still decided after other statuses.
+type ProcessedNotification struct {
+	TransactionID string    `json:"transaction_id"`
+	AccountID     string    `json:"account_id"`
+	Status        string    `json:"status"`
+	Decision      string    `json:"decision"`
+	Reason        string    `json:"reason"`
+	Deliveries    int       `json:"deliveries"`
+	ProcessedAt   time.Time `json:"processed_at"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+}
+
+// FeeVersion identifies a tariff adjustment of an account by its position
+// in time. Stored per account, it is the adjustment whose fee the account
+// has: notifications of older adjustments are stale.
+type FeeVersion struct {
+	AccountID     string `json:"account_id"`
+	TransactionID string `json:"transaction_id"`
+	// Sequence is the position of the adjustment in the backend history of
+	// the account, from 1; 0 when the backend does not list it.
+	Sequence int `json:"sequence,omitempty"`
+	// RequestedAt is when this API sent the adjustment; unset for
+	// adjustments sent by others.
+	RequestedAt *time.Time `json:"requested_at,omitempty"`
+	NewFee      float64    `json:"new_fee"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+// Compare orders v and w by backend sequence when both have one, else by
+// request time. It returns -1, 0 or +1 and the name of the field compared,
+// which is empty when neither can be.
+func (v FeeVersion) Compare(w FeeVersion) (int, string) {
+	switch {
+	case v.Sequence > 0 && w.Sequence > 0:
+		return cmp.Compare(v.Sequence, w.Sequence), "sequence"
+	case v.RequestedAt != nil && w.RequestedAt != nil:
+		return v.RequestedAt.Compare(*w.RequestedAt), "requested_at"
+	}
+	return 0, ""
+}
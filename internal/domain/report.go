@@ -1,8 +1,102 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
 // Report holds fintech summary metrics: total accounts, counts by type, top accounts by fee.
 type Report struct {
-	TotalAccounts  int                `json:"total_accounts"`
-	TotalsByType   map[string]int     `json:"totals_by_type"`
-	Top100ByFee    []Account          `json:"top_100_by_fee"`
+	TotalAccounts int            `json:"total_accounts"`
+	TotalsByType  map[string]int `json:"totals_by_type"`
+	// Top100ByFee is always the 100 highest fees; Top is only set when the
+	// request customizes the ranking size or order.
+	Top100ByFee []Account           `json:"top_100_by_fee"`
+	Top         *TopAccounts        `json:"top,omitempty"`
+	Groups      map[string]FeeStats `json:"groups,omitempty"`
+	Histogram   []HistogramBucket   `json:"histogram,omitempty"`
+}
+
+// TopAccounts is a ranking of accounts by monthly fee.
+type TopAccounts struct {
+	Limit    int       `json:"limit"`
+	Order    string    `json:"order"`
+	Accounts []Account `json:"accounts"`
+}
+
+// FeeStats aggregates the monthly fees of a group of accounts.
+type FeeStats struct {
+	Count  int     `json:"count"`
+	Sum    float64 `json:"sum"`
+	Avg    float64 `json:"avg"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+}
+
+// HistogramBucket counts the accounts with From <= monthly_fee < To.
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// Report ranking orders.
+const (
+	OrderDesc = "desc"
+	OrderAsc  = "asc"
+)
+
+// Report parameter limits.
+const (
+	DefaultReportTop    = 100
+	MaxReportTop        = 1000
+	MaxHistogramBuckets = 1000
+)
+
+// ErrInvalidReportParams is wrapped by every ReportParams validation error.
+var ErrInvalidReportParams = errors.New("invalid report parameters")
+
+// ReportParams customizes a Report. The zero value is not valid; start from
+// DefaultReportParams.
+type ReportParams struct {
+	// Top is the size of the fee ranking.
+	Top int
+	// Order is OrderDesc (highest fees first) or OrderAsc.
+	Order string
+	// Type restricts the report to accounts of that type.
+	Type string
+	// GroupBy is "" or "type"; groups get fee aggregates.
+	GroupBy string
+	// HistogramWidth, when positive, adds a fee histogram with buckets that wide.
+	HistogramWidth float64
+}
+
+// DefaultReportParams returns the parameters of the classic top-100 report.
+func DefaultReportParams() ReportParams {
+	return ReportParams{Top: DefaultReportTop, Order: OrderDesc}
+}
+
+// IsDefaultRanking reports whether p asks for the classic top-100 ranking.
+func (p ReportParams) IsDefaultRanking() bool {
+	return p.Top == DefaultReportTop && p.Order == OrderDesc
+}
+
+// Validate checks p against the report parameter limits.
+func (p ReportParams) Validate() error {
+	if p.Top < 1 || p.Top > MaxReportTop {
+		return fmt.Errorf("%w: top must be between 1 and %d", ErrInvalidReportParams, MaxReportTop)
+	}
+	if p.Order != OrderDesc && p.Order != OrderAsc {
+		return fmt.Errorf("%w: order must be %q or %q", ErrInvalidReportParams, OrderAsc, OrderDesc)
+	}
+	if p.GroupBy != "" && p.GroupBy != "type" {
+		return fmt.Errorf("%w: group_by only supports \"type\"", ErrInvalidReportParams)
+	}
+	if w := p.HistogramWidth; math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+		return fmt.Errorf("%w: histogram bucket width must be a positive finite number", ErrInvalidReportParams)
+	}
+	return nil
 }
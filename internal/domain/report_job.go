@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Report job kinds.
+const (
+	// ReportJobReport renders a report with the given parameters.
+	ReportJobReport = "report"
+	// ReportJobCatalog exports every account of the catalog.
+	ReportJobCatalog = "catalog"
+)
+
+// Report job statuses.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+var (
+	// ErrTooManyJobs is returned when a client already has as many active jobs
+	// as it may.
+	ErrTooManyJobs = errors.New("too many active jobs for this client")
+	// ErrJobQueueFull is returned when the job queue cannot take more work.
+	ErrJobQueueFull = errors.New("report job queue is full")
+	// ErrJobNotReady is returned when a job result is requested before the
+	// job succeeded.
+	ErrJobNotReady = errors.New("report job has no result")
+)
+
+// ReportJobRequest describes the work of a report job.
+type ReportJobRequest struct {
+	// Kind is ReportJobReport (default) or ReportJobCatalog.
+	Kind string `json:"kind"`
+	// Format is json (default), csv, ndjson or xlsx.
+	Format string `json:"format"`
+	// Tables selects the report tables of a csv, ndjson or xlsx result.
+	Tables []string `json:"tables,omitempty"`
+	// Locale "pt-BR" writes csv numbers with decimal commas.
+	Locale string `json:"locale,omitempty"`
+	// Top, Order, Type, GroupBy and Histogram mirror the /v1/report query
+	// parameters; they only apply to report jobs.
+	Top       int     `json:"top,omitempty"`
+	Order     string  `json:"order,omitempty"`
+	Type      string  `json:"type,omitempty"`
+	GroupBy   string  `json:"group_by,omitempty"`
+	Histogram float64 `json:"histogram,omitempty"`
+}
+
+// ReportParams returns the report parameters of r, defaulting like /v1/report.
+func (r ReportJobRequest) ReportParams() ReportParams {
+	p := DefaultReportParams()
+	if r.Top != 0 {
+		p.Top = r.Top
+	}
+	if r.Order != "" {
+		p.Order = r.Order
+	}
+	p.Type = r.Type
+	p.GroupBy = r.GroupBy
+	p.HistogramWidth = r.Histogram
+	return p
+}
+
+// JobOwner is the caller submitting a job. ID scopes which jobs it sees;
+// Quota is what the per-client job limit counts, and is shared by callers
+// that cannot be told apart, like anonymous ones behind one IP.
+type JobOwner struct {
+	ID    string
+	Quota string
+}
+
+// ReportJob is the state of an asynchronous report job.
+type ReportJob struct {
+	ID       string `json:"id"`
+	ClientID string `json:"client_id"`
+	// Quota is the Quota of the job's owner.
+	Quota   string           `json:"-"`
+	Status  string           `json:"status"`
+	Request ReportJobRequest `json:"request"`
+	// ProcessedAccounts counts the catalog accounts read so far.
+	ProcessedAccounts int        `json:"processed_accounts"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+	// ExpiresAt is when a finished job and its result are discarded.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Finished reports whether the job reached a final status.
+func (j ReportJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// ReportSnapshotInfo identifies a persisted report snapshot.
+type ReportSnapshotInfo struct {
+	ID            string    `json:"id"`
+	TakenAt       time.Time `json:"taken_at"`
+	TotalAccounts int       `json:"total_accounts"`
+}
+
+// ReportSnapshot is a default report as it was at TakenAt.
+type ReportSnapshot struct {
+	ReportSnapshotInfo
+	Report Report `json:"report"`
+}
+
+// ReportDiff describes how the fee landscape changed between two snapshots.
+type ReportDiff struct {
+	From               ReportSnapshotInfo `json:"from"`
+	To                 ReportSnapshotInfo `json:"to"`
+	TotalAccountsDelta int                `json:"total_accounts_delta"`
+	// TotalsByTypeDelta only lists types whose count changed.
+	TotalsByTypeDelta map[string]int  `json:"totals_by_type_delta"`
+	EnteredTop        []RankedAccount `json:"entered_top"`
+	LeftTop           []RankedAccount `json:"left_top"`
+	// FeeChanges lists accounts present in both top lists whose fee changed.
+	FeeChanges []TopFeeChange `json:"fee_changes"`
+}
+
+// RankedAccount is an account with its 1-based position in a top list.
+type RankedAccount struct {
+	Account
+	Rank int `json:"rank"`
+}
+
+// TopFeeChange is a fee change of an account that stayed in the top list.
+type TopFeeChange struct {
+	AccountID string  `json:"account_id"`
+	Name      string  `json:"name"`
+	OldFee    float64 `json:"old_fee"`
+	NewFee    float64 `json:"new_fee"`
+	OldRank   int     `json:"old_rank"`
+	NewRank   int     `json:"new_rank"`
+}
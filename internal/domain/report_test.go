@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestReportParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *ReportParams)
+		valid  bool
+	}{
+		{"default", func(p *ReportParams) {}, true},
+		{"ascending by type with histogram", func(p *ReportParams) {
+			p.Order, p.GroupBy, p.HistogramWidth = OrderAsc, "type", 10
+		}, true},
+		{"top zero", func(p *ReportParams) { p.Top = 0 }, false},
+		{"top too large", func(p *ReportParams) { p.Top = MaxReportTop + 1 }, false},
+		{"unknown order", func(p *ReportParams) { p.Order = "random" }, false},
+		{"unknown group", func(p *ReportParams) { p.GroupBy = "name" }, false},
+		{"negative width", func(p *ReportParams) { p.HistogramWidth = -1 }, false},
+		{"NaN width", func(p *ReportParams) { p.HistogramWidth = math.NaN() }, false},
+		{"infinite width", func(p *ReportParams) { p.HistogramWidth = math.Inf(1) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := DefaultReportParams()
+			tt.modify(&p)
+			err := p.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidReportParams) {
+				t.Errorf("Validate() = %v, want ErrInvalidReportParams", err)
+			}
+		})
+	}
+}
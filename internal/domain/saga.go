@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Saga statuses. Completed and compensated are final; failed means a
+// compensation failed and an operator has to look at the saga.
+const (
+	SagaRunning      = "running"
+	SagaCompleted    = "completed"
+	SagaCompensating = "compensating"
+	SagaCompensated  = "compensated"
+	SagaFailed       = "failed"
+)
+
+// Saga step statuses.
+const (
+	StepPending     = "pending"
+	StepRunning     = "running"
+	StepDone        = "done"
+	StepFailed      = "failed"
+	StepCompensated = "compensated"
+)
+
+// ErrSagaRunning is returned when starting a saga that is already running.
+var ErrSagaRunning = errors.New("saga is already running")
+
+// Saga is the persisted state of the pipeline that sends a tariff adjustment
+// to the backend. Its ID is the adjustment's transaction ID.
+type Saga struct {
+	ID     string                  `json:"id"`
+	Input  TariffAdjustmentRequest `json:"input"`
+	Status string                  `json:"status"`
+	// Runs counts the times the saga was started; a compensated saga runs
+	// again when its adjustment is retried.
+	Runs      int        `json:"runs"`
+	Steps     []SagaStep `json:"steps"`
+	Error     string     `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// SagaStep is one step of a saga. Steps of the same Stage run in parallel;
+// stages run in order.
+type SagaStep struct {
+	Name       string     `json:"name"`
+	Stage      int        `json:"stage"`
+	Status     string     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether s reached a final status.
+func (s Saga) Finished() bool {
+	return s.Status == SagaCompleted || s.Status == SagaCompensated
+}
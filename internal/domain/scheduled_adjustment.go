@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Scheduled adjustment statuses.
+const (
+	// SchedulePending waits for its effective time, or for a retry.
+	SchedulePending = "pending"
+	// ScheduleStarted was sent to the backend at its effective time.
+	ScheduleStarted = "started"
+	// ScheduleFailed could not be sent to the backend after every attempt.
+	ScheduleFailed = "failed"
+	// ScheduleCancelled was cancelled before it was sent.
+	ScheduleCancelled = "cancelled"
+)
+
+var (
+	// ErrInvalidSchedule is returned for scheduled adjustments that cannot be
+	// accepted, such as one without a future effective time.
+	ErrInvalidSchedule = errors.New("invalid scheduled adjustment")
+	// ErrScheduleNotPending is returned when cancelling a scheduled adjustment
+	// that already left the pending state.
+	ErrScheduleNotPending = errors.New("scheduled adjustment is no longer pending")
+)
+
+// ScheduledAdjustment is a tariff adjustment held back until EffectiveAt,
+// when its approval flow starts. Its ID becomes the transaction ID.
+type ScheduledAdjustment struct {
+	ID          string    `json:"id"`
+	AccountID   string    `json:"account_id"`
+	NewFee      float64   `json:"new_fee"`
+	EffectiveAt time.Time `json:"effective_at"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Status      string    `json:"status"`
+	// Attempts counts the tries to send the adjustment; failed tries are
+	// retried from NextAttemptAt on.
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// Request returns the adjustment to send for s.
+func (s ScheduledAdjustment) Request() TariffAdjustmentRequest {
+	return TariffAdjustmentRequest{
+		TransactionID: s.ID,
+		AccountID:     s.AccountID,
+		NewFee:        s.NewFee,
+		RequestedBy:   s.RequestedBy,
+	}
+}
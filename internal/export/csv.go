@@ -0,0 +1,69 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var _ Writer = (*CSVWriter)(nil)
+
+// NewCSVWriter writes a header line and one line per row to w.
+func NewCSVWriter(w io.Writer, opts Options) *CSVWriter {
+	cw := csv.NewWriter(w)
+	if opts.decimalComma() {
+		cw.Comma = ';'
+	}
+	return &CSVWriter{w: cw, opts: opts}
+}
+
+type CSVWriter struct {
+	w     *csv.Writer
+	opts  Options
+	table *Table
+	rec   []string
+}
+
+func (c *CSVWriter) Begin(t Table) error {
+	if c.table != nil {
+		return fmt.Errorf("export: CSV holds a single table, already writing %s", c.table.Name)
+	}
+	c.table = &t
+	c.rec = make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		c.rec[i] = col.Name
+	}
+	return c.w.Write(c.rec)
+}
+
+func (c *CSVWriter) Row(values ...interface{}) error {
+	if err := checkRow(c.table, values); err != nil {
+		return err
+	}
+	for i, v := range values {
+		if s, ok := formatNumber(v); ok {
+			if c.opts.decimalComma() {
+				s = strings.Replace(s, ".", ",", 1)
+			}
+			c.rec[i] = s
+			continue
+		}
+		c.rec[i] = escapeFormula(fmt.Sprint(v))
+	}
+	return c.w.Write(c.rec)
+}
+
+func (c *CSVWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula prefixes text that a spreadsheet would evaluate as a formula
+// with a quote, so account names cannot inject formulas into finance sheets.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
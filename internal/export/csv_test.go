@@ -0,0 +1,98 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	table := Table{Name: "accounts", Columns: []Column{
+		{Name: "name", Type: String},
+		{Name: "monthly_fee", Type: Number},
+		{Name: "count", Type: Integer},
+	}}
+	tests := []struct {
+		name   string
+		locale string
+		rows   [][]interface{}
+		want   string
+	}{
+		{
+			name: "default",
+			rows: [][]interface{}{{"Ana", 12.5, 3}, {"Bia, Jr.", -0.25, int64(0)}},
+			want: "name,monthly_fee,count\nAna,12.5,3\n\"Bia, Jr.\",-0.25,0\n",
+		},
+		{
+			name:   "pt-BR",
+			locale: "pt-BR",
+			rows:   [][]interface{}{{"Ana", 12.5, 3}, {"Bia; Jr.", 1234.5, 10}},
+			want:   "name;monthly_fee;count\nAna;12,5;3\n\"Bia; Jr.\";1234,5;10\n",
+		},
+		{
+			name:   "pt_BR",
+			locale: "pt_br",
+			rows:   [][]interface{}{{"Ana", 0.1, 1}},
+			want:   "name;monthly_fee;count\nAna;0,1;1\n",
+		},
+		{
+			name: "formulas",
+			rows: [][]interface{}{{"=SUM(A1)", -5.0, 1}, {"+1", 2.0, 2}, {"-1", 3.0, 3}, {"@x", 4.0, 4}},
+			want: "name,monthly_fee,count\n'=SUM(A1),-5,1\n'+1,2,2\n'-1,3,3\n'@x,4,4\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			w := NewCSVWriter(&b, Options{Locale: tt.locale})
+			if err := w.Begin(table); err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range tt.rows {
+				if err := w.Row(row...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVWriterErrors(t *testing.T) {
+	w := NewCSVWriter(&strings.Builder{}, Options{})
+	if err := w.Row("a"); err == nil {
+		t.Error("Row before Begin: want an error")
+	}
+	if err := w.Begin(Table{Name: "t", Columns: []Column{{Name: "a"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Row("a", "b"); err == nil {
+		t.Error("Row with too many values: want an error")
+	}
+	if err := w.Begin(Table{Name: "u"}); err == nil {
+		t.Error("second Begin: want an error")
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"Ana", "Ana"},
+		{"=1+1", "'=1+1"},
+		{"+55 11", "'+55 11"},
+		{"-x", "'-x"},
+		{"@user", "'@user"},
+		{"\tx", "'\tx"},
+		{"\rx", "'\rx"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := escapeFormula(tt.in); got != tt.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
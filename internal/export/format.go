@@ -0,0 +1,120 @@
+// Package export writes tabular data as CSV, NDJSON or XLSX, one row at a
+// time, so large exports never have to be buffered in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatXLSX   Format = "xlsx"
+)
+
+// ContentType returns the media type served for f.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/json"
+}
+
+// mediaTypes maps accepted media types to formats.
+var mediaTypes = map[string]Format{
+	"application/json":     FormatJSON,
+	"text/csv":             FormatCSV,
+	"application/x-ndjson": FormatNDJSON,
+	"application/ndjson":   FormatNDJSON,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FormatXLSX,
+}
+
+// Negotiate picks the format from an explicit ?format= value or, when that is
+// empty, from the Accept header. Accept values naming no supported type fall
+// back to JSON; an unknown ?format= is an error.
+func Negotiate(format, accept string) (Format, error) {
+	if format != "" {
+		switch f := Format(strings.ToLower(format)); f {
+		case FormatJSON, FormatCSV, FormatNDJSON, FormatXLSX:
+			return f, nil
+		}
+		return "", fmt.Errorf("unsupported format %q; use json, csv, ndjson or xlsx", format)
+	}
+	type candidate struct {
+		format Format
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		f, ok := mediaTypes[mt]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{f, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return FormatJSON, nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].format, nil
+}
+
+// Options tunes how values are rendered.
+type Options struct {
+	// Locale "pt-BR" writes CSV numbers with a decimal comma and separates
+	// fields with ";", as spreadsheets configured for Brazil expect. JSON and
+	// XLSX numbers are locale-independent and ignore it.
+	Locale string
+}
+
+func (o Options) decimalComma() bool {
+	return strings.EqualFold(o.Locale, "pt-BR") || strings.EqualFold(o.Locale, "pt_BR")
+}
+
+// Writer writes one or more tables. Begin starts a table and Row appends a row
+// to it with one value per column. CSV and NDJSON hold a single table; XLSX
+// writes each table to its own sheet. Close flushes and must always be called.
+type Writer interface {
+	Begin(t Table) error
+	Row(values ...interface{}) error
+	Close() error
+}
+
+// NewWriter returns the Writer for f on w. FormatJSON has no row writer.
+func NewWriter(f Format, w io.Writer, opts Options) (Writer, error) {
+	switch f {
+	case FormatCSV:
+		return NewCSVWriter(w, opts), nil
+	case FormatNDJSON:
+		return NewNDJSONWriter(w), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w), nil
+	}
+	return nil, fmt.Errorf("no row writer for format %q", f)
+}
@@ -0,0 +1,26 @@
+package export
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		format, accept string
+		want           Format
+		wantErr        bool
+	}{
+		{"", "", FormatJSON, false},
+		{"CSV", "application/json", FormatCSV, false},
+		{"xml", "", "", true},
+		{"", "text/csv", FormatCSV, false},
+		{"", "text/html, application/x-ndjson", FormatNDJSON, false},
+		{"", "text/csv;q=0.5, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", FormatXLSX, false},
+		{"", "text/csv;q=0", FormatJSON, false},
+		{"", "text/html", FormatJSON, false},
+	}
+	for _, tt := range tests {
+		got, err := Negotiate(tt.format, tt.accept)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Negotiate(%q, %q) = %q, %v; want %q, error %v", tt.format, tt.accept, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
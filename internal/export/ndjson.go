@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+var _ Writer = (*NDJSONWriter)(nil)
+
+// NewNDJSONWriter writes each row to w as a JSON object on its own line, keyed
+// by column name in column order.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	n := &NDJSONWriter{w: bufio.NewWriter(w)}
+	n.enc = json.NewEncoder(&n.buf)
+	n.enc.SetEscapeHTML(false)
+	return n
+}
+
+type NDJSONWriter struct {
+	w     *bufio.Writer
+	table *Table
+	buf   bytes.Buffer
+	enc   *json.Encoder
+}
+
+func (n *NDJSONWriter) Begin(t Table) error {
+	if n.table != nil {
+		return fmt.Errorf("export: NDJSON holds a single table, already writing %s", n.table.Name)
+	}
+	n.table = &t
+	return nil
+}
+
+func (n *NDJSONWriter) Row(values ...interface{}) error {
+	if err := checkRow(n.table, values); err != nil {
+		return err
+	}
+	// Encode by hand rather than through a map so keys keep column order.
+	n.buf.Reset()
+	n.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			n.buf.WriteByte(',')
+		}
+		if err := n.encode(n.table.Columns[i].Name); err != nil {
+			return err
+		}
+		n.buf.WriteByte(':')
+		if err := n.encode(v); err != nil {
+			return err
+		}
+	}
+	n.buf.WriteString("}\n")
+	_, err := n.w.Write(n.buf.Bytes())
+	return err
+}
+
+// encode appends v to buf without the newline json.Encoder adds.
+func (n *NDJSONWriter) encode(v interface{}) error {
+	if err := n.enc.Encode(v); err != nil {
+		return err
+	}
+	n.buf.Truncate(n.buf.Len() - 1)
+	return nil
+}
+
+func (n *NDJSONWriter) Close() error {
+	return n.w.Flush()
+}
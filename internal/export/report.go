@@ -0,0 +1,207 @@
+package export
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"sre/internal/domain"
+)
+
+// Tables exported by /v1/search and /v1/report.
+var (
+	AccountsTable = Table{
+		Name:        "accounts",
+		Title:       "Account",
+		Description: "One account of a /v1/search export.",
+		Columns: []Column{
+			{Name: "id", Type: String},
+			{Name: "name", Type: String},
+			{Name: "type", Type: String},
+			{Name: "monthly_fee", Type: Number},
+		},
+	}
+	TopTable = Table{
+		Name:        "top",
+		Title:       "Report ranking row",
+		Description: "One account of the /v1/report fee ranking, best first.",
+		Columns: []Column{
+			{Name: "rank", Type: Integer, Description: "1-based position in the ranking"},
+			{Name: "id", Type: String},
+			{Name: "name", Type: String},
+			{Name: "type", Type: String},
+			{Name: "monthly_fee", Type: Number},
+		},
+	}
+	TotalsTable = Table{
+		Name:        "totals",
+		Title:       "Report totals row",
+		Description: "Number of accounts of one type in /v1/report.",
+		Columns: []Column{
+			{Name: "type", Type: String},
+			{Name: "accounts", Type: Integer},
+		},
+	}
+	GroupsTable = Table{
+		Name:        "groups",
+		Title:       "Report group row",
+		Description: "Monthly fee aggregates of one account type (/v1/report?group_by=type).",
+		Columns: []Column{
+			{Name: "group", Type: String},
+			{Name: "count", Type: Integer},
+			{Name: "sum", Type: Number},
+			{Name: "avg", Type: Number},
+			{Name: "min", Type: Number},
+			{Name: "max", Type: Number},
+			{Name: "median", Type: Number},
+			{Name: "p90", Type: Number},
+		},
+	}
+	HistogramTable = Table{
+		Name:        "histogram",
+		Title:       "Report histogram row",
+		Description: "Accounts with from <= monthly_fee < to (/v1/report?histogram=width).",
+		Columns: []Column{
+			{Name: "from", Type: Number},
+			{Name: "to", Type: Number},
+			{Name: "count", Type: Integer},
+		},
+	}
+)
+
+// Tables lists every exported table by name, for schema publication.
+var Tables = map[string]Table{
+	AccountsTable.Name:  AccountsTable,
+	TopTable.Name:       TopTable,
+	TotalsTable.Name:    TotalsTable,
+	GroupsTable.Name:    GroupsTable,
+	HistogramTable.Name: HistogramTable,
+}
+
+// ReportTables are the tables of a report, in workbook sheet order.
+var ReportTables = []string{TotalsTable.Name, TopTable.Name, GroupsTable.Name, HistogramTable.Name}
+
+// ReportTablesFor validates the report tables requested for format f and
+// fills in the default. CSV and NDJSON hold one table, the ranking by
+// default; XLSX gets every table as its own sheet by default.
+func ReportTablesFor(f Format, names []string) ([]string, error) {
+	for _, t := range names {
+		if !slices.Contains(ReportTables, t) {
+			return nil, fmt.Errorf("unknown table %q; use %s", t, strings.Join(ReportTables, ", "))
+		}
+	}
+	switch {
+	case f == FormatXLSX && len(names) == 0:
+		return ReportTables, nil
+	case f == FormatXLSX || f == FormatJSON:
+		return names, nil
+	case len(names) == 0:
+		return []string{TopTable.Name}, nil
+	case len(names) > 1:
+		return nil, fmt.Errorf("%s exports hold a single table; use xlsx for several", f)
+	}
+	return names, nil
+}
+
+// WriteAccounts writes accounts as AccountsTable rows, restricted to the
+// named columns in that order when fields is not empty.
+func WriteAccounts(w Writer, accounts []domain.Account, fields []string) error {
+	t := AccountsTable
+	if len(fields) > 0 {
+		t.Columns = make([]Column, 0, len(fields))
+		for _, f := range fields {
+			i := slices.IndexFunc(AccountsTable.Columns, func(c Column) bool { return c.Name == f })
+			if i < 0 {
+				return fmt.Errorf("unknown account column %q", f)
+			}
+			t.Columns = append(t.Columns, AccountsTable.Columns[i])
+		}
+	}
+	if err := w.Begin(t); err != nil {
+		return err
+	}
+	row := make([]interface{}, len(t.Columns))
+	for _, a := range accounts {
+		for i, c := range t.Columns {
+			switch c.Name {
+			case "id":
+				row[i] = a.ID
+			case "name":
+				row[i] = a.Name
+			case "type":
+				row[i] = a.Type
+			case "monthly_fee":
+				row[i] = a.MonthlyFee
+			}
+		}
+		if err := w.Row(row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteReport writes the named tables of rep. The ranking is the custom top
+// when the report has one, else the top 100. Groups and histogram are empty
+// unless the report was asked for them.
+func WriteReport(w Writer, rep domain.Report, tables []string) error {
+	for _, name := range tables {
+		t, ok := Tables[name]
+		if !ok || name == AccountsTable.Name {
+			return fmt.Errorf("unknown report table %q", name)
+		}
+		if err := w.Begin(t); err != nil {
+			return err
+		}
+		if err := writeReportTable(w, rep, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReportTable(w Writer, rep domain.Report, name string) error {
+	switch name {
+	case TotalsTable.Name:
+		types := make([]string, 0, len(rep.TotalsByType))
+		for t := range rep.TotalsByType {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			if err := w.Row(t, rep.TotalsByType[t]); err != nil {
+				return err
+			}
+		}
+	case TopTable.Name:
+		top := rep.Top100ByFee
+		if rep.Top != nil {
+			top = rep.Top.Accounts
+		}
+		for i, a := range top {
+			if err := w.Row(i+1, a.ID, a.Name, a.Type, a.MonthlyFee); err != nil {
+				return err
+			}
+		}
+	case GroupsTable.Name:
+		groups := make([]string, 0, len(rep.Groups))
+		for g := range rep.Groups {
+			groups = append(groups, g)
+		}
+		sort.Strings(groups)
+		for _, g := range groups {
+			s := rep.Groups[g]
+			if err := w.Row(g, s.Count, s.Sum, s.Avg, s.Min, s.Max, s.Median, s.P90); err != nil {
+				return err
+			}
+		}
+	case HistogramTable.Name:
+		for _, b := range rep.Histogram {
+			if err := w.Row(b.From, b.To, b.Count); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ColumnType is the type of a column's values.
+type ColumnType string
+
+const (
+	String  ColumnType = "string"
+	Number  ColumnType = "number"
+	Integer ColumnType = "integer"
+)
+
+// Column describes one column of a Table.
+type Column struct {
+	Name        string
+	Type        ColumnType
+	Description string
+}
+
+// Table describes the rows of an export. Name is used for XLSX sheet names
+// and schema IDs.
+type Table struct {
+	Name        string
+	Title       string
+	Description string
+	Columns     []Column
+}
+
+// Schema returns a JSON Schema describing one NDJSON row of t.
+func (t Table) Schema() map[string]interface{} {
+	props := make(map[string]interface{}, len(t.Columns))
+	required := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		p := map[string]interface{}{"type": string(c.Type)}
+		if c.Description != "" {
+			p["description"] = c.Description
+		}
+		props[c.Name] = p
+		required = append(required, c.Name)
+	}
+	return map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  "/v1/schemas/" + t.Name,
+		"title":                t.Title,
+		"description":          t.Description,
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func checkRow(t *Table, values []interface{}) error {
+	if t == nil {
+		return fmt.Errorf("export: Row called before Begin")
+	}
+	if len(values) != len(t.Columns) {
+		return fmt.Errorf("export: table %s has %d columns, got %d values", t.Name, len(t.Columns), len(values))
+	}
+	return nil
+}
+
+// formatNumber renders a numeric value with the shortest exact
+// representation, or "" when v is not a number.
+func formatNumber(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(n), true
+	case int64:
+		return strconv.FormatInt(n, 10), true
+	}
+	return "", false
+}
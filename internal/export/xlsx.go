@@ -0,0 +1,171 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var _ Writer = (*XLSXWriter)(nil)
+
+// NewXLSXWriter writes an Office Open XML workbook to w with one sheet per
+// table. Sheets are streamed into the zip as rows arrive; the package parts
+// that list the sheets are written on Close.
+func NewXLSXWriter(w io.Writer) *XLSXWriter {
+	return &XLSXWriter{zip: zip.NewWriter(w)}
+}
+
+type XLSXWriter struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	table  *Table
+	sheets []string
+	row    int
+}
+
+func (x *XLSXWriter) Begin(t Table) error {
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+	f, err := x.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)+1))
+	if err != nil {
+		return err
+	}
+	x.sheets = append(x.sheets, sheetName(t.Name))
+	x.table = &t
+	x.sheet = bufio.NewWriter(f)
+	x.row = 0
+	x.sheet.WriteString(xml.Header)
+	x.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]interface{}, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.Name
+	}
+	return x.writeRow(header, 1)
+}
+
+func (x *XLSXWriter) Row(values ...interface{}) error {
+	if err := checkRow(x.table, values); err != nil {
+		return err
+	}
+	return x.writeRow(values, 0)
+}
+
+// writeRow appends a row with cells in the given style (1 is the bold header).
+func (x *XLSXWriter) writeRow(values []interface{}, style int) error {
+	x.row++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.row)
+	for i, v := range values {
+		ref := fmt.Sprintf("%s%d", columnName(i), x.row)
+		s := ""
+		if style > 0 {
+			s = fmt.Sprintf(` s="%d"`, style)
+		}
+		if n, ok := formatNumber(v); ok {
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, s, n)
+			continue
+		}
+		fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, s)
+		if err := xml.EscapeText(x.sheet, []byte(fmt.Sprint(v))); err != nil {
+			return err
+		}
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *XLSXWriter) endSheet() error {
+	if x.sheet == nil {
+		return nil
+	}
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	err := x.sheet.Flush()
+	x.sheet = nil
+	return err
+}
+
+func (x *XLSXWriter) Close() error {
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+	if len(x.sheets) == 0 {
+		// A workbook needs at least one sheet.
+		if err := x.Begin(Table{Name: "empty"}); err != nil {
+			return err
+		}
+		if err := x.endSheet(); err != nil {
+			return err
+		}
+	}
+	var types, sheets, rels strings.Builder
+	for i, name := range x.sheets {
+		n := i + 1
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name, n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(x.sheets)+1)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+	}
+	for _, p := range parts {
+		f, err := x.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+p.body); err != nil {
+			return err
+		}
+	}
+	return x.zip.Close()
+}
+
+// columnName returns the spreadsheet column letters for a 0-based index.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// sheetName makes s a valid sheet name: at most 31 characters and none of
+// the characters Excel reserves.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\"<>&'`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > 31 {
+		s = s[:31]
+	}
+	if s == "" {
+		s = "sheet"
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+package export
+
+import "testing"
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		i    int
+		want string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+		{16383, "XFD"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.i); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"accounts", "accounts"},
+		{"", "sheet"},
+		{"a/b:c", "a_b_c"},
+		{"by_type[checking]", "by_type_checking_"},
+		{"abcdefghijklmnopqrstuvwxyz0123456789", "abcdefghijklmnopqrstuvwxyz01234"},
+	}
+	for _, tt := range tests {
+		if got := sheetName(tt.in); got != tt.want {
+			t.Errorf("sheetName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package flags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Flags holds the runtime-tunable settings. Consumers read them on every call,
+// so changes take effect without a rebuild or restart.
+type Flags struct {
+	RetryEnabled     bool     `json:"retry_enabled"`
+	RetryMaxAttempts int      `json:"retry_max_attempts"`
+	RetryBackoff     Duration `json:"retry_backoff"`
+
+	BreakerEnabled   bool     `json:"breaker_enabled"`
+	BreakerThreshold int      `json:"breaker_threshold"`
+	BreakerCooldown  Duration `json:"breaker_cooldown"`
+
+	SearchCacheEnabled bool     `json:"search_cache_enabled"`
+	SearchCacheTTL     Duration `json:"search_cache_ttl"`
+
+	AdjustmentAsync   bool     `json:"adjustment_async"`
+	AdjustmentTimeout Duration `json:"adjustment_timeout"`
+
+	SheddingEnabled          bool    `json:"shedding_enabled"`
+	SheddingLatencyTolerance float64 `json:"shedding_latency_tolerance"`
+}
+
+// Defaults returns the settings the service starts with. They match the
+// behavior of a build without any flag overrides.
+func Defaults() Flags {
+	return Flags{
+		RetryEnabled:       false,
+		RetryMaxAttempts:   3,
+		RetryBackoff:       Duration(100 * time.Millisecond),
+		BreakerEnabled:     false,
+		BreakerThreshold:   5,
+		BreakerCooldown:    Duration(5 * time.Second),
+		SearchCacheEnabled: false,
+		SearchCacheTTL:     Duration(5 * time.Second),
+		AdjustmentAsync:    true,
+		AdjustmentTimeout:  Duration(30 * time.Second),
+		SheddingEnabled:    false,
+		// A route is overloaded once its latency doubles over its baseline.
+		SheddingLatencyTolerance: 2,
+	}
+}
+
+// Validate reports whether f holds usable values.
+func (f Flags) Validate() error {
+	var errs []error
+	if f.RetryMaxAttempts < 1 || f.RetryMaxAttempts > 10 {
+		errs = append(errs, fmt.Errorf("retry_max_attempts must be between 1 and 10, got %d", f.RetryMaxAttempts))
+	}
+	if f.RetryBackoff < 0 {
+		errs = append(errs, errors.New("retry_backoff must not be negative"))
+	}
+	if f.BreakerThreshold < 1 {
+		errs = append(errs, fmt.Errorf("breaker_threshold must be at least 1, got %d", f.BreakerThreshold))
+	}
+	if f.BreakerCooldown < 0 {
+		errs = append(errs, errors.New("breaker_cooldown must not be negative"))
+	}
+	if f.SearchCacheTTL < 0 {
+		errs = append(errs, errors.New("search_cache_ttl must not be negative"))
+	}
+	if f.AdjustmentTimeout <= 0 {
+		errs = append(errs, errors.New("adjustment_timeout must be positive"))
+	}
+	if f.SheddingLatencyTolerance <= 1 {
+		errs = append(errs, fmt.Errorf("shedding_latency_tolerance must be greater than 1, got %g", f.SheddingLatencyTolerance))
+	}
+	return errors.Join(errs...)
+}
+
+// Duration is a time.Duration that encodes to JSON as a string like "250ms".
+type Duration time.Duration
+
+// Std returns d as a time.Duration.
+func (d Duration) Std() time.Duration { return time.Duration(d) }
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"250ms\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,145 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store holds the current Flags. Reads are lock-free; writes are serialized
+// and every change is audited in the log.
+type Store struct {
+	mu       sync.Mutex
+	current  atomic.Pointer[Flags]
+	defaults Flags
+}
+
+// NewStore creates a Store initialized with defaults.
+func NewStore(defaults Flags) *Store {
+	s := &Store{defaults: defaults}
+	s.current.Store(&defaults)
+	return s
+}
+
+// Get returns the current flags. A nil Store yields Defaults, so consumers
+// wired without a store keep the built-in behavior.
+func (s *Store) Get() Flags {
+	if s == nil {
+		return Defaults()
+	}
+	return *s.current.Load()
+}
+
+// Update merges the JSON object patch onto the current flags. Fields absent
+// from patch keep their value.
+func (s *Store) Update(actor string, patch []byte) (Flags, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next, err := merge(s.Get(), patch)
+	if err != nil {
+		return Flags{}, err
+	}
+	s.swap(actor, "api", next)
+	return next, nil
+}
+
+// Reset restores the defaults the Store was created with.
+func (s *Store) Reset(actor string) Flags {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.swap(actor, "reset", s.defaults)
+	return s.defaults
+}
+
+// LoadFile replaces the current flags with defaults overlaid by the JSON
+// object in path.
+func (s *Store) LoadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next, err := merge(s.defaults, b)
+	if err != nil {
+		return fmt.Errorf("flags file %s: %w", path, err)
+	}
+	s.swap("file:"+path, "file", next)
+	return nil
+}
+
+// WatchFile polls path every interval and reloads it when it changes. It
+// returns when ctx is done. Invalid files are logged and ignored.
+func (s *Store) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	var lastSize int64 = -1
+	if fi, err := os.Stat(path); err == nil {
+		lastMod, lastSize = fi.ModTime(), fi.Size()
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		fi, err := os.Stat(path)
+		if err != nil || (fi.ModTime().Equal(lastMod) && fi.Size() == lastSize) {
+			continue
+		}
+		lastMod, lastSize = fi.ModTime(), fi.Size()
+		if err := s.LoadFile(path); err != nil {
+			slog.Error("reload runtime flags failed", "path", path, "err", err)
+		}
+	}
+}
+
+func (s *Store) swap(actor, source string, next Flags) {
+	prev := s.Get()
+	s.current.Store(&next)
+	changes := diff(prev, next)
+	if len(changes) == 0 {
+		return
+	}
+	slog.Info("runtime flags changed", "actor", actor, "source", source, "changes", changes)
+}
+
+func merge(base Flags, patch []byte) (Flags, error) {
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&base); err != nil {
+		return Flags{}, err
+	}
+	if err := base.Validate(); err != nil {
+		return Flags{}, err
+	}
+	return base, nil
+}
+
+// diff lists the fields that differ between a and b as "name: old -> new".
+func diff(a, b Flags) []string {
+	am, bm := toMap(a), toMap(b)
+	var out []string
+	for k, v := range bm {
+		if old := am[k]; fmt.Sprint(old) != fmt.Sprint(v) {
+			out = append(out, fmt.Sprintf("%s: %v -> %v", k, old, v))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func toMap(f Flags) map[string]interface{} {
+	b, _ := json.Marshal(f)
+	m := make(map[string]interface{})
+	_ = json.Unmarshal(b, &m)
+	return m
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/auth"
+)
+
+// AccessControl authenticates requests and enforces the scope each route
+// requires.
+type AccessControl struct {
+	authn  auth.Authenticator
+	scopes map[string]string
+	exempt []string
+}
+
+// NewAccessControl creates an AccessControl. scopes maps routes, written like
+// "GET /v1/accounts/{id}", to the scope they require; other routes only need
+// an authenticated or anonymous caller. Routes in exempt are not checked at
+// all and carry no client.
+func NewAccessControl(authn auth.Authenticator, scopes map[string]string, exempt ...string) *AccessControl {
+	return &AccessControl{authn: authn, scopes: scopes, exempt: exempt}
+}
+
+// Middleware answers 401 to requests that fail authentication and 403 to
+// clients without the route's scope, and attaches the client to the request
+// context of the others. It must be installed on router itself.
+func (a *AccessControl) Middleware(router chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := matchRoute(router, r)
+			if !ok || slices.Contains(a.exempt, route) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			client, err := a.authn.Authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer, ApiKey header="X-API-Key"`)
+				msg := "authentication required"
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					msg = "invalid credentials"
+					slog.WarnContext(r.Context(), "authentication failed", "route", route, "remote", remoteIP(r), "err", err)
+				}
+				encodeError(w, msg, http.StatusUnauthorized)
+				return
+			}
+			ctx := auth.WithClient(r.Context(), client)
+			if scope, ok := a.scopes[route]; ok && !client.HasScope(scope) {
+				slog.WarnContext(ctx, "missing scope", "route", route, "scope", scope)
+				encodeError(w, "missing scope "+scope, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
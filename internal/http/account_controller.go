@@ -1,8 +1,12 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -28,6 +32,9 @@ func (c *AccountController) Routes(r chi.Router) {
 		r.Get("/{id}", c.getAccount)
 		r.Post("/{id}/tariff-adjustments", c.createTariffAdjustment)
 		r.Get("/{id}/tariff-adjustments", c.getTariffAdjustments)
+		r.Get("/{id}/fee-history", c.getFeeHistory)
+		r.Get("/{id}/scheduled-adjustments", c.listScheduledAdjustments)
+		r.Delete("/{id}/scheduled-adjustments/{sid}", c.cancelScheduledAdjustment)
 		r.Post("/notifications", c.notifications)
 	})
 }
@@ -71,13 +78,55 @@ func (c *AccountController) createTariffAdjustment(w http.ResponseWriter, r *htt
 		TransactionID: uuid.NewString(),
 		NewFee:        payload.NewFee,
 	}
-	if err := c.usecase.SendTariffAdjustmentRequest(r.Context(), input); err != nil {
+	dryRun, err := parseBoolParam(r.URL.Query(), "dry_run")
+	if err != nil {
 		encodeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if dryRun {
+		c.previewTariffAdjustment(w, r, input)
+		return
+	}
+	if payload.EffectiveAt != nil && payload.EffectiveAt.After(time.Now()) {
+		scheduled, err := c.usecase.ScheduleTariffAdjustment(r.Context(), input, *payload.EffectiveAt)
+		if err != nil {
+			encodeScheduleError(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/v1/accounts/"+id+"/scheduled-adjustments/"+scheduled.ID)
+		encodeJSON(w, scheduled, http.StatusAccepted)
+		return
+	}
+	if err := c.usecase.SendTariffAdjustmentRequest(r.Context(), input); err != nil {
+		encodeSendError(w, r, err)
+		return
+	}
+	// The answer stays 204; Location names the adjustment for cancelling
+	// and reverting it.
+	w.Header().Set("Location", tariffAdjustmentURL(input.TransactionID))
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// previewTariffAdjustment answers a dry run: what input would do, projected
+// on the report selected by the report query parameters.
+func (c *AccountController) previewTariffAdjustment(w http.ResponseWriter, r *http.Request, input domain.TariffAdjustmentRequest) {
+	params, err := parseReportParams(r.URL.Query())
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	preview, err := c.usecase.PreviewTariffAdjustment(r.Context(), input, params)
+	switch {
+	case errors.Is(err, domain.ErrInvalidBulkRequest), errors.Is(err, domain.ErrInvalidReportParams):
+		encodeError(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "preview tariff adjustment failed", "account_id", input.AccountID, "err", err)
+		encodeError(w, "preview tariff adjustment failed", http.StatusInternalServerError)
+	default:
+		encodeJSON(w, preview, http.StatusOK)
+	}
+}
+
 func (c *AccountController) getTariffAdjustments(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
@@ -104,13 +153,94 @@ func (c *AccountController) getTariffAdjustments(w http.ResponseWriter, r *http.
 	encodeJSON(w, out, http.StatusOK)
 }
 
+func (c *AccountController) getFeeHistory(w http.ResponseWriter, r *http.Request) {
+	q := domain.FeeHistoryQuery{AccountID: chi.URLParam(r, "id")}
+	var err error
+	if q.From, q.To, err = parseTimeRange(r.URL.Query()); err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.Offset, err = parseIntParam(r.URL.Query(), "offset"); err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.Limit, err = parseIntParam(r.URL.Query(), "limit"); err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := c.usecase.GetFeeHistory(r.Context(), q)
+	switch {
+	case errors.Is(err, domain.ErrInvalidFeeHistoryQuery):
+		encodeError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrNotFound):
+		encodeError(w, "account not found", http.StatusNotFound)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "get fee history failed", "account_id", q.AccountID, "err", err)
+		encodeError(w, "get fee history failed", http.StatusInternalServerError)
+	default:
+		encodeJSON(w, page, http.StatusOK)
+	}
+}
+
+func (c *AccountController) listScheduledAdjustments(w http.ResponseWriter, r *http.Request) {
+	list, err := c.usecase.ListScheduledAdjustments(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		encodeScheduleError(w, r, err)
+		return
+	}
+	encodeJSON(w, list, http.StatusOK)
+}
+
+func (c *AccountController) cancelScheduledAdjustment(w http.ResponseWriter, r *http.Request) {
+	a, err := c.usecase.CancelScheduledAdjustment(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "sid"))
+	if err != nil {
+		encodeScheduleError(w, r, err)
+		return
+	}
+	encodeJSON(w, a, http.StatusOK)
+}
+
+// encodeSendError answers a failed adjustment request: 400 when it is
+// invalid, 504 when the backend timed out, 502 when it failed otherwise.
+// Details of server-side failures only go to the log.
+func encodeSendError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, domain.ErrInvalidAdjustment) {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.ErrorContext(r.Context(), "tariff adjustment request failed", "err", err)
+	switch {
+	case errors.Is(err, domain.ErrBackendCall) && errors.Is(err, context.DeadlineExceeded):
+		encodeError(w, "tariff adjustment timed out in the backend", http.StatusGatewayTimeout)
+	case errors.Is(err, domain.ErrBackendCall):
+		encodeError(w, "tariff adjustment failed in the backend", http.StatusBadGateway)
+	default:
+		encodeError(w, "tariff adjustment request failed", http.StatusInternalServerError)
+	}
+}
+
+func encodeScheduleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSchedule), errors.Is(err, domain.ErrInvalidAdjustment):
+		encodeError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrNotFound):
+		encodeError(w, "scheduled adjustment not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrScheduleNotPending):
+		encodeError(w, err.Error(), http.StatusConflict)
+	default:
+		slog.ErrorContext(r.Context(), "scheduled adjustment request failed", "err", err)
+		encodeError(w, "scheduled adjustment request failed", http.StatusInternalServerError)
+	}
+}
+
 func (c *AccountController) notifications(w http.ResponseWriter, r *http.Request) {
 	var msg NotificationMessage
 	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 		encodeError(w, "invalid body", http.StatusBadRequest)
 		return
 	}
-	if err := c.usecase.UpdateFee(r.Context(), msg.AccountID); err != nil {
+	n := domain.FeeNotification{TransactionID: msg.TransactionID, AccountID: msg.AccountID, Status: msg.Status}
+	if err := c.usecase.UpdateFee(r.Context(), n); err != nil {
 		encodeError(w, "update fee failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -126,6 +256,8 @@ type GetAccountResponse struct {
 
 type TariffAdjustmentPayload struct {
 	NewFee float64 `json:"new_fee"`
+	// EffectiveAt, when in the future, holds the adjustment until then.
+	EffectiveAt *time.Time `json:"effective_at,omitempty"`
 }
 
 type TariffAdjustmentResponse struct {
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/auth"
+	"sre/internal/flags"
+)
+
+// NewAdminController creates an admin controller. Its routes are meant to
+// require the admin scope through AccessControl.
+func NewAdminController(store *flags.Store) *AdminController {
+	return &AdminController{flags: store}
+}
+
+type AdminController struct {
+	flags *flags.Store
+}
+
+// Routes registers admin routes on r.
+func (c *AdminController) Routes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/flags", c.getFlags)
+		r.Patch("/flags", c.updateFlags)
+		r.Delete("/flags", c.resetFlags)
+	})
+}
+
+func (c *AdminController) getFlags(w http.ResponseWriter, r *http.Request) {
+	encodeJSON(w, c.flags.Get(), http.StatusOK)
+}
+
+func (c *AdminController) updateFlags(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		encodeError(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	f, err := c.flags.Update(adminActor(r), body)
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	encodeJSON(w, f, http.StatusOK)
+}
+
+func (c *AdminController) resetFlags(w http.ResponseWriter, r *http.Request) {
+	encodeJSON(w, c.flags.Reset(adminActor(r)), http.StatusOK)
+}
+
+// adminActor names who changed the flags in their change log.
+func adminActor(r *http.Request) string {
+	name := "admin"
+	if c, ok := auth.ClientFrom(r.Context()); ok {
+		name = c.Name
+	}
+	return name + "@" + r.RemoteAddr
+}
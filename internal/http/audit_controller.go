@@ -0,0 +1,67 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+// NewAuditController creates a controller for the audit log.
+func NewAuditController(s usecases.AuditService) *AuditController {
+	return &AuditController{service: s}
+}
+
+type AuditController struct {
+	service usecases.AuditService
+}
+
+// Routes registers audit routes on r.
+func (c *AuditController) Routes(r chi.Router) {
+	r.Get("/audit", c.query)
+}
+
+func (c *AuditController) query(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := c.service.Query(r.Context(), q)
+	if errors.Is(err, domain.ErrInvalidAuditQuery) {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "audit query failed", "err", err)
+		encodeError(w, "audit query failed", http.StatusInternalServerError)
+		return
+	}
+	encodeJSON(w, page, http.StatusOK)
+}
+
+// parseAuditQuery reads account_id, from and to (RFC 3339), after and limit
+// from q.
+func parseAuditQuery(q url.Values) (domain.AuditQuery, error) {
+	aq := domain.AuditQuery{AccountID: q.Get("account_id")}
+	var err error
+	if aq.From, aq.To, err = parseTimeRange(q); err != nil {
+		return aq, err
+	}
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return aq, fmt.Errorf("after must be a sequence number, got %q", v)
+		}
+		aq.AfterSeq = n
+	}
+	aq.Limit, err = parseIntParam(q, "limit")
+	return aq, err
+}
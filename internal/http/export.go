@@ -0,0 +1,46 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"sre/internal/export"
+)
+
+// negotiateFormat picks the response format from ?format= or the Accept
+// header. It answers 400 itself and returns false on an unknown ?format=.
+func negotiateFormat(w http.ResponseWriter, r *http.Request) (export.Format, bool) {
+	f, err := export.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return f, true
+}
+
+// writeExport streams a CSV, NDJSON or XLSX download named like
+// "<name>-20261019T091500Z.csv". ?locale=pt-BR switches CSV to decimal commas.
+// Errors after the headers are sent can only be logged.
+func writeExport(w http.ResponseWriter, r *http.Request, f export.Format, name string, write func(export.Writer) error) {
+	ew, err := export.NewWriter(f, w, export.Options{Locale: r.URL.Query().Get("locale")})
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102T150405Z"), f)
+	h := w.Header()
+	h.Set("Content-Type", f.ContentType())
+	h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	h.Set("Vary", "Accept, Accept-Encoding")
+	h.Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	err = write(ew)
+	if cerr := ew.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "export failed", "format", f, "name", name, "err", err)
+	}
+}
@@ -1,8 +1,19 @@
 package http
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func encodeJSON(w http.ResponseWriter, v interface{}, status int) {
@@ -18,3 +29,106 @@ func encodeError(w http.ResponseWriter, msg string, status int) {
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
 }
+
+// encodeCachedJSON writes v like encodeJSON with a 200 status, plus a strong
+// ETag derived from the encoded content and caching headers that let the
+// client reuse it for maxAge. Responses may need a scope, so shared caches
+// must not store them. A request whose If-None-Match matches the ETag gets a
+// bodiless 304 instead.
+func encodeCachedJSON(w http.ResponseWriter, r *http.Request, v interface{}, maxAge time.Duration) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		encodeError(w, "encode response failed", http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", fmt.Sprintf("private, max-age=%d, must-revalidate", int(maxAge.Seconds())))
+	h.Set("Vary", "Accept, Accept-Encoding, Authorization, X-API-Key")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// etagMatches implements the weak comparison If-None-Match requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute returns the route r is for, written as "METHOD /pattern" like
+// "GET /v1/accounts/{id}", by matching it against router. It works from
+// middleware installed on router, including a router mounted under a prefix.
+func matchRoute(router chi.Routes, r *http.Request) (string, bool) {
+	path, prefix := r.URL.Path, ""
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		path = rctx.RoutePath
+		prefix = strings.TrimSuffix(rctx.RoutePattern(), "/*")
+	}
+	tctx := chi.NewRouteContext()
+	if !router.Match(tctx, r.Method, path) {
+		return "", false
+	}
+	return r.Method + " " + prefix + tctx.RoutePattern(), true
+}
+
+// remoteIP returns the IP of the peer that sent r.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parseTimeRange reads the optional RFC 3339 "from" and "to" parameters.
+func parseTimeRange(q url.Values) (from, to time.Time, err error) {
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if v := q.Get(p.name); v != "" {
+			if *p.dst, err = time.Parse(time.RFC3339, v); err != nil {
+				return from, to, fmt.Errorf("%s must be an RFC 3339 time, got %q", p.name, v)
+			}
+		}
+	}
+	return from, to, nil
+}
+
+// parseIntParam reads an optional integer parameter, 0 when absent.
+func parseIntParam(q url.Values, name string) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer, got %q", name, v)
+	}
+	return n, nil
+}
+
+// parseBoolParam reads an optional boolean parameter, false when absent.
+func parseBoolParam(q url.Values, name string) (bool, error) {
+	v := q.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean, got %q", name, v)
+	}
+	return b, nil
+}
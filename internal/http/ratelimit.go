@@ -0,0 +1,158 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/auth"
+	"sre/internal/metrics"
+)
+
+// idleBucketSweep is how often buckets that refilled completely are dropped.
+const idleBucketSweep = time.Minute
+
+// RateLimiter enforces the token-bucket limits of authenticated clients,
+// overall and per route.
+type RateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	requests *metrics.Vec
+	limited  *metrics.Vec
+}
+
+// NewRateLimiter creates a RateLimiter.
+func NewRateLimiter(reg *metrics.Registry) *RateLimiter {
+	return &RateLimiter{
+		buckets:  make(map[string]*tokenBucket),
+		requests: reg.Counter("sre_http_requests_by_client_total", "Inbound requests by API client.", "client"),
+		limited:  reg.Counter("sre_http_rate_limited_total", "Inbound requests rejected by rate limits.", "client", "route"),
+	}
+}
+
+// Middleware applies the rate limits of the client in the request context,
+// which AccessControl puts there; requests without one are not limited. It
+// must be installed on router itself.
+func (l *RateLimiter) Middleware(router chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := matchRoute(router, r)
+			client, authenticated := auth.ClientFrom(r.Context())
+			if !ok || !authenticated {
+				next.ServeHTTP(w, r)
+				return
+			}
+			bucketKey := "client:" + client.Name
+			if client.Anonymous {
+				// Anonymous callers get a bucket per remote IP.
+				bucketKey = "anon:" + remoteIP(r)
+			}
+			l.requests.With(client.Name).Inc()
+			limits := []auth.RateLimit{client.RateLimit, client.RouteLimits[route]}
+			keys := []string{bucketKey, bucketKey + " " + route}
+			allowed, remaining, limit, wait := l.take(keys, limits)
+			if limit > 0 {
+				h := w.Header()
+				h.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+				h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+				h.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			}
+			if !allowed {
+				l.limited.With(client.Name, route).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+				encodeError(w, fmt.Sprintf("rate limit exceeded for %s", client.Name), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// take consumes a token from every limited bucket, or from none if any is
+// empty. It reports the tightest bucket: its burst, the tokens it has left
+// and, when denied, how long until a token is available, else until it is
+// full again. limit is 0 when nothing is limited.
+func (l *RateLimiter) take(keys []string, limits []auth.RateLimit) (allowed bool, remaining, limit int, wait time.Duration) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	var buckets []*tokenBucket
+	for i, lim := range limits {
+		if lim.Unlimited() {
+			continue
+		}
+		b, ok := l.buckets[keys[i]]
+		if !ok || b.limit != lim {
+			b = &tokenBucket{limit: lim, tokens: float64(lim.Burst), updated: now}
+			l.buckets[keys[i]] = b
+		}
+		b.refill(now)
+		buckets = append(buckets, b)
+	}
+	if len(buckets) == 0 {
+		return true, 0, 0, 0
+	}
+	allowed = true
+	for _, b := range buckets {
+		if b.tokens < 1 {
+			allowed = false
+		}
+	}
+	remaining = math.MaxInt
+	for _, b := range buckets {
+		if allowed {
+			b.tokens--
+		}
+		if r := int(b.tokens); r < remaining || (r == remaining && b.limit.Burst < limit) {
+			remaining, limit = r, b.limit.Burst
+			if allowed {
+				wait = b.untilTokens(float64(b.limit.Burst))
+			} else {
+				wait = b.untilTokens(1)
+			}
+		}
+	}
+	return allowed, remaining, limit, wait
+}
+
+// sweep drops buckets that are full again, since a fresh bucket is the
+// same; the caller holds l.mu.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketSweep {
+		return
+	}
+	l.lastSweep = now
+	for k, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+type tokenBucket struct {
+	limit   auth.RateLimit
+	tokens  float64
+	updated time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*b.limit.RPS)
+	b.updated = now
+}
+
+// untilTokens is how long until the bucket holds n tokens.
+func (b *tokenBucket) untilTokens(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.limit.RPS * float64(time.Second))
+}
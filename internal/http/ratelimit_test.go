@@ -0,0 +1,92 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"sre/internal/auth"
+)
+
+func TestRateLimiterTake(t *testing.T) {
+	// Refills are negligible at this rate, so every call sees the tokens the
+	// previous ones left.
+	slow := func(burst int) auth.RateLimit { return auth.RateLimit{RPS: 0.001, Burst: burst} }
+	type call struct {
+		keys      []string
+		limits    []auth.RateLimit
+		allowed   bool
+		remaining int
+		limit     int
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name: "unlimited",
+			calls: []call{
+				{[]string{"a", "a r"}, []auth.RateLimit{{}, {}}, true, 0, 0},
+			},
+		},
+		{
+			name: "burst then denied",
+			calls: []call{
+				{[]string{"a"}, []auth.RateLimit{slow(2)}, true, 1, 2},
+				{[]string{"a"}, []auth.RateLimit{slow(2)}, true, 0, 2},
+				{[]string{"a"}, []auth.RateLimit{slow(2)}, false, 0, 2},
+			},
+		},
+		{
+			name: "separate keys",
+			calls: []call{
+				{[]string{"a"}, []auth.RateLimit{slow(1)}, true, 0, 1},
+				{[]string{"b"}, []auth.RateLimit{slow(1)}, true, 0, 1},
+				{[]string{"a"}, []auth.RateLimit{slow(1)}, false, 0, 1},
+			},
+		},
+		{
+			name: "tightest bucket reported",
+			calls: []call{
+				{[]string{"a", "a r"}, []auth.RateLimit{slow(5), slow(2)}, true, 1, 2},
+				{[]string{"a", "a r"}, []auth.RateLimit{slow(5), slow(2)}, true, 0, 2},
+				// The route bucket is empty, so the client bucket keeps its
+				// tokens.
+				{[]string{"a", "a r"}, []auth.RateLimit{slow(5), slow(2)}, false, 0, 2},
+				{[]string{"a", "a s"}, []auth.RateLimit{slow(5), {}}, true, 2, 5},
+			},
+		},
+		{
+			name: "changed limit starts a new bucket",
+			calls: []call{
+				{[]string{"a"}, []auth.RateLimit{slow(1)}, true, 0, 1},
+				{[]string{"a"}, []auth.RateLimit{slow(3)}, true, 2, 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &RateLimiter{buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+			for i, c := range tt.calls {
+				allowed, remaining, limit, wait := l.take(c.keys, c.limits)
+				if allowed != c.allowed || remaining != c.remaining || limit != c.limit {
+					t.Errorf("call %d: take = %v, %d, %d; want %v, %d, %d", i, allowed, remaining, limit, c.allowed, c.remaining, c.limit)
+				}
+				if !allowed && wait <= 0 {
+					t.Errorf("call %d: denied with wait %v, want a positive wait", i, wait)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterTakeRefills(t *testing.T) {
+	l := &RateLimiter{buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+	lim := []auth.RateLimit{{RPS: 1000, Burst: 1}}
+	if allowed, _, _, _ := l.take([]string{"a"}, lim); !allowed {
+		t.Fatal("first call denied")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if allowed, _, _, _ := l.take([]string{"a"}, lim); !allowed {
+		t.Error("call after the bucket refilled denied")
+	}
+}
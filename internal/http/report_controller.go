@@ -1,14 +1,25 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"sre/internal/domain"
+	"sre/internal/export"
 	"sre/internal/usecases"
 )
 
+// reportMaxAge is how long clients may reuse a report without revalidating.
+const reportMaxAge = 5 * time.Second
+
 // NewReportController creates a report controller.
 func NewReportController(s usecases.ReportService) *ReportController {
 	return &ReportController{service: s}
@@ -24,11 +35,61 @@ func (c *ReportController) Routes(r chi.Router) {
 }
 
 func (c *ReportController) getReport(w http.ResponseWriter, r *http.Request) {
-	rep, err := c.service.GetReport(r.Context())
+	params, err := parseReportParams(r.URL.Query())
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+	tables, err := export.ReportTablesFor(format, strings.FieldsFunc(r.URL.Query().Get("table"), func(r rune) bool { return r == ',' }))
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep, err := c.service.GetReport(r.Context(), params)
+	if errors.Is(err, domain.ErrInvalidReportParams) {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(r.Context(), "get report failed", "err", err)
 		encodeError(w, "get report failed", http.StatusInternalServerError)
 		return
 	}
-	encodeJSON(w, rep, http.StatusOK)
+	if format != export.FormatJSON {
+		writeExport(w, r, format, "report", func(ew export.Writer) error {
+			return export.WriteReport(ew, rep, tables)
+		})
+		return
+	}
+	encodeCachedJSON(w, r, rep, reportMaxAge)
+}
+
+// parseReportParams reads top, order, type, group_by and histogram from q,
+// defaulting to the classic top-100 report.
+func parseReportParams(q url.Values) (domain.ReportParams, error) {
+	p := domain.DefaultReportParams()
+	if v := q.Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("top must be an integer, got %q", v)
+		}
+		p.Top = n
+	}
+	if v := q.Get("order"); v != "" {
+		p.Order = v
+	}
+	p.Type = q.Get("type")
+	p.GroupBy = q.Get("group_by")
+	if v := q.Get("histogram"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil || w <= 0 {
+			return p, fmt.Errorf("histogram must be a positive bucket width, got %q", v)
+		}
+		p.HistogramWidth = w
+	}
+	return p, p.Validate()
 }
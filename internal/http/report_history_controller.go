@@ -0,0 +1,63 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+// NewReportHistoryController creates a controller for report snapshots.
+func NewReportHistoryController(s usecases.ReportHistoryService) *ReportHistoryController {
+	return &ReportHistoryController{service: s}
+}
+
+type ReportHistoryController struct {
+	service usecases.ReportHistoryService
+}
+
+// Routes registers report snapshot routes on r.
+func (c *ReportHistoryController) Routes(r chi.Router) {
+	r.Get("/reports/snapshots", c.listSnapshots)
+	r.Post("/reports/snapshots", c.takeSnapshot)
+	r.Get("/reports/diff", c.diff)
+}
+
+func (c *ReportHistoryController) listSnapshots(w http.ResponseWriter, r *http.Request) {
+	list, err := c.service.ListSnapshots(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "list report snapshots failed", "err", err)
+		encodeError(w, "list report snapshots failed", http.StatusInternalServerError)
+		return
+	}
+	encodeJSON(w, list, http.StatusOK)
+}
+
+func (c *ReportHistoryController) takeSnapshot(w http.ResponseWriter, r *http.Request) {
+	info, err := c.service.TakeSnapshot(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "take report snapshot failed", "err", err)
+		encodeError(w, "take report snapshot failed", http.StatusInternalServerError)
+		return
+	}
+	encodeJSON(w, info, http.StatusCreated)
+}
+
+func (c *ReportHistoryController) diff(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	d, err := c.service.Diff(r.Context(), q.Get("from"), q.Get("to"))
+	if errors.Is(err, domain.ErrNotFound) {
+		encodeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "report diff failed", "err", err)
+		encodeError(w, "report diff failed", http.StatusInternalServerError)
+		return
+	}
+	encodeJSON(w, d, http.StatusOK)
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/auth"
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+// NewReportJobController creates a controller for asynchronous report jobs.
+func NewReportJobController(s usecases.ReportJobService) *ReportJobController {
+	return &ReportJobController{service: s}
+}
+
+type ReportJobController struct {
+	service usecases.ReportJobService
+}
+
+// Routes registers report job routes on r.
+func (c *ReportJobController) Routes(r chi.Router) {
+	r.Post("/reports/jobs", c.submit)
+	r.Get("/reports/jobs/{id}", c.get)
+	r.Delete("/reports/jobs/{id}", c.cancel)
+	r.Get("/reports/jobs/{id}/result", c.result)
+}
+
+// ReportJobResponse is a job's state plus the link to its result once it
+// succeeded.
+type ReportJobResponse struct {
+	domain.ReportJob
+	ResultURL string `json:"result_url,omitempty"`
+}
+
+func (c *ReportJobController) submit(w http.ResponseWriter, r *http.Request) {
+	var req domain.ReportJobRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		encodeError(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	job, err := c.service.Submit(r.Context(), jobOwner(r), req)
+	if err != nil {
+		c.encodeJobError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", jobURL(job.ID))
+	encodeJSON(w, jobResponse(job), http.StatusAccepted)
+}
+
+func (c *ReportJobController) get(w http.ResponseWriter, r *http.Request) {
+	job, err := c.service.Get(r.Context(), clientID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		c.encodeJobError(w, r, err)
+		return
+	}
+	encodeJSON(w, jobResponse(job), http.StatusOK)
+}
+
+func (c *ReportJobController) cancel(w http.ResponseWriter, r *http.Request) {
+	job, err := c.service.Cancel(r.Context(), clientID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		c.encodeJobError(w, r, err)
+		return
+	}
+	encodeJSON(w, jobResponse(job), http.StatusOK)
+}
+
+func (c *ReportJobController) result(w http.ResponseWriter, r *http.Request) {
+	f, res, err := c.service.OpenResult(r.Context(), clientID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		c.encodeJobError(w, r, err)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", res.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, res.Name))
+	http.ServeContent(w, r, res.Name, res.Modified, f)
+}
+
+func (c *ReportJobController) encodeJobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidReportParams):
+		encodeError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrNotFound):
+		encodeError(w, "report job not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrJobNotReady):
+		encodeError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrTooManyJobs):
+		w.Header().Set("Retry-After", "10")
+		encodeError(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, domain.ErrJobQueueFull):
+		w.Header().Set("Retry-After", "10")
+		encodeError(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		slog.ErrorContext(r.Context(), "report job request failed", "err", err)
+		encodeError(w, "report job request failed", http.StatusInternalServerError)
+	}
+}
+
+func jobResponse(job domain.ReportJob) ReportJobResponse {
+	resp := ReportJobResponse{ReportJob: job}
+	if job.Status == domain.JobSucceeded {
+		resp.ResultURL = jobURL(job.ID) + "/result"
+	}
+	return resp
+}
+
+func jobURL(id string) string {
+	return "/v1/reports/jobs/" + id
+}
+
+// clientID identifies the caller for job ownership: the authenticated API
+// client, else the X-Client-ID header, else the remote IP. Each source has
+// its own prefix so that a header cannot name an API client, and header IDs
+// are scoped to the remote IP so that they cannot name a caller elsewhere.
+func clientID(r *http.Request) string {
+	return jobOwner(r).ID
+}
+
+// jobOwner is the caller as the owner of the jobs it submits. The
+// X-Client-ID header of an anonymous caller only scopes which jobs it sees:
+// its per-client limit is counted per remote IP, so that changing the header
+// does not lift it.
+func jobOwner(r *http.Request) domain.JobOwner {
+	if c, ok := auth.ClientFrom(r.Context()); ok && !c.Anonymous {
+		id := "client:" + c.Name
+		return domain.JobOwner{ID: id, Quota: id}
+	}
+	ip := remoteIP(r)
+	owner := domain.JobOwner{ID: "ip:" + ip, Quota: "ip:" + ip}
+	if id := strings.TrimSpace(r.Header.Get("X-Client-ID")); id != "" {
+		owner.ID = "hdr:" + ip + "/" + id
+	}
+	return owner
+}
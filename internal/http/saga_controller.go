@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+// NewSagaController creates a controller exposing adjustment sagas.
+func NewSagaController(s usecases.SagaService) *SagaController {
+	return &SagaController{service: s}
+}
+
+type SagaController struct {
+	service usecases.SagaService
+}
+
+// Routes registers saga routes on r.
+func (c *SagaController) Routes(r chi.Router) {
+	r.Get("/sagas", c.list)
+	r.Get("/sagas/{id}", c.get)
+}
+
+// list returns the sagas, oldest first; ?status= keeps those with that
+// status, e.g. failed for the ones an operator has to look at.
+func (c *SagaController) list(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", domain.SagaRunning, domain.SagaCompleted, domain.SagaCompensating, domain.SagaCompensated, domain.SagaFailed:
+	default:
+		encodeError(w, "unknown saga status "+status, http.StatusBadRequest)
+		return
+	}
+	sagas, err := c.service.List(r.Context(), status)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "list sagas failed", "err", err)
+		encodeError(w, "list sagas failed", http.StatusInternalServerError)
+		return
+	}
+	encodeJSON(w, sagas, http.StatusOK)
+}
+
+func (c *SagaController) get(w http.ResponseWriter, r *http.Request) {
+	saga, err := c.service.Get(r.Context(), chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		encodeError(w, "saga not found", http.StatusNotFound)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "get saga failed", "err", err)
+		encodeError(w, "get saga failed", http.StatusInternalServerError)
+	default:
+		encodeJSON(w, saga, http.StatusOK)
+	}
+}
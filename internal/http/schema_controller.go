@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/export"
+)
+
+// NewSchemaController creates a controller publishing the JSON Schemas of
+// NDJSON export rows.
+func NewSchemaController() *SchemaController {
+	return &SchemaController{}
+}
+
+type SchemaController struct{}
+
+// Routes registers schema routes on r.
+func (c *SchemaController) Routes(r chi.Router) {
+	r.Get("/schemas", c.list)
+	r.Get("/schemas/{name}", c.get)
+}
+
+func (c *SchemaController) list(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(export.Tables))
+	for name := range export.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	encodeJSON(w, names, http.StatusOK)
+}
+
+func (c *SchemaController) get(w http.ResponseWriter, r *http.Request) {
+	t, ok := export.Tables[chi.URLParam(r, "name")]
+	if !ok {
+		encodeError(w, "schema not found", http.StatusNotFound)
+		return
+	}
+	encodeCachedJSON(w, r, t.Schema(), time.Hour)
+}
@@ -1,15 +1,23 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"sre/internal/domain"
+	"sre/internal/export"
+	"sre/internal/query"
 	"sre/internal/usecases"
 	"sre/internal/utils"
 )
 
+// searchMaxAge is how long clients may reuse a search result without revalidating.
+const searchMaxAge = 5 * time.Second
+
 // NewSearchController creates a search controller.
 func NewSearchController(s usecases.SearchService) *SearchController {
 	return &SearchController{usecase: s}
@@ -24,11 +32,67 @@ func (c *SearchController) Routes(r chi.Router) {
 	r.Get("/search", c.search)
 }
 
+// search answers either a plain term search (?term=) or a structured query
+// (?q=type:checking fee>20), optionally sorted (?sort=-monthly_fee) and
+// projected (?fields=id,monthly_fee), as JSON or as a CSV, NDJSON or XLSX
+// export (?format= or Accept).
 func (c *SearchController) search(w http.ResponseWriter, r *http.Request) {
-	term := r.URL.Query().Get("term")
-	accounts, err := c.usecase.SearchAccountsByTerm(r.Context(), term)
+	params := r.URL.Query()
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+	fields, err := query.ParseFields(params.Get("fields"))
 	if err != nil {
-		encodeError(w, err.Error(), http.StatusInternalServerError)
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sortKeys, err := query.ParseSort(params.Get("sort"))
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var accounts []domain.Account
+	if q := params.Get("q"); q != "" {
+		expr, err := query.Parse(q)
+		if err != nil {
+			var perr *query.ParseError
+			if errors.As(err, &perr) {
+				encodeJSON(w, QueryErrorResponse{Error: perr.Msg, Position: perr.Pos}, http.StatusBadRequest)
+				return
+			}
+			encodeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		accounts, err = c.usecase.QueryAccounts(r.Context(), expr, sortKeys)
+		if err != nil {
+			encodeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		accounts, err = c.usecase.SearchAccountsByTerm(r.Context(), params.Get("term"))
+		if err != nil {
+			encodeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(sortKeys) > 0 {
+			// Results may be shared with a cache; sort a copy.
+			accounts = slices.Clone(accounts)
+			query.Sort(accounts, sortKeys)
+		}
+	}
+	if format != export.FormatJSON {
+		columns, _ := utils.Map(fields, func(f query.Field) string { return string(f) })
+		writeExport(w, r, format, "search", func(ew export.Writer) error {
+			return export.WriteAccounts(ew, accounts, columns)
+		})
+		return
+	}
+	if len(fields) > 0 {
+		out, _ := utils.Map(accounts, func(a domain.Account) map[string]interface{} {
+			return query.Project(a, fields)
+		})
+		encodeCachedJSON(w, r, ProjectedSearchResponse{Data: out}, searchMaxAge)
 		return
 	}
 	out, err := utils.Map(accounts, func(a domain.Account) SearchResultItem {
@@ -43,7 +107,7 @@ func (c *SearchController) search(w http.ResponseWriter, r *http.Request) {
 		encodeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	encodeJSON(w, SearchResponse{Data: out}, http.StatusOK)
+	encodeCachedJSON(w, r, SearchResponse{Data: out}, searchMaxAge)
 }
 
 type SearchResultItem struct {
@@ -56,3 +120,12 @@ type SearchResultItem struct {
 type SearchResponse struct {
 	Data []SearchResultItem `json:"data"`
 }
+
+type ProjectedSearchResponse struct {
+	Data []map[string]interface{} `json:"data"`
+}
+
+type QueryErrorResponse struct {
+	Error    string `json:"error"`
+	Position int    `json:"position"`
+}
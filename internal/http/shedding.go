@@ -0,0 +1,251 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/flags"
+	"sre/internal/metrics"
+)
+
+// Priority is the load-shedding class of a route. Under pressure, lower
+// classes are shed first so cheap, latency-sensitive calls keep flowing.
+type Priority int
+
+const (
+	// PriorityCritical is for cheap reads, callbacks and operator routes.
+	PriorityCritical Priority = iota
+	// PriorityNormal is the class of routes without an explicit one.
+	PriorityNormal
+	// PriorityLow is for expensive routes such as reports and exports.
+	PriorityLow
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityCritical:
+		return "critical"
+	case PriorityLow:
+		return "low"
+	}
+	return "normal"
+}
+
+// share is the fraction of the global concurrency limit a class may occupy;
+// the rest is headroom for higher classes.
+func (p Priority) share() float64 {
+	switch p {
+	case PriorityCritical:
+		return 1
+	case PriorityLow:
+		return 0.5
+	}
+	return 0.8
+}
+
+// Concurrency limit bounds.
+const (
+	routeInitialLimit  = 64
+	routeMaxLimit      = 512
+	globalInitialLimit = 256
+	globalMaxLimit     = 2048
+	minLimit           = 2
+)
+
+// LoadShedder limits the requests in flight per route and overall with AIMD
+// limits driven by observed latency, and answers excess requests at once with
+// 503 instead of letting everything slow down together.
+type LoadShedder struct {
+	flags      *flags.Store
+	priorities map[string]Priority
+	global     *aimdLimiter
+
+	mu     sync.Mutex
+	routes map[string]*aimdLimiter
+
+	shed     *metrics.Vec
+	limits   *metrics.Vec
+	inflight *metrics.Vec
+}
+
+// NewLoadShedder creates a LoadShedder. priorities maps routes, written as
+// "METHOD /pattern" like "GET /v1/accounts/{id}", to their class; other
+// routes are PriorityNormal. It is a no-op while shedding_enabled is off.
+func NewLoadShedder(store *flags.Store, reg *metrics.Registry, priorities map[string]Priority) *LoadShedder {
+	return &LoadShedder{
+		flags:      store,
+		priorities: priorities,
+		global:     newAIMDLimiter(globalInitialLimit, globalMaxLimit),
+		routes:     make(map[string]*aimdLimiter),
+		shed:       reg.Counter("sre_http_shed_total", "Inbound requests rejected by the load shedder.", "route", "priority"),
+		limits:     reg.Gauge("sre_http_concurrency_limit", "Current adaptive concurrency limit.", "route"),
+		inflight:   reg.Gauge("sre_http_inflight_requests", "Inbound requests in flight under the load shedder.", "route"),
+	}
+}
+
+// Middleware sheds requests to the routes of router. It must be installed on
+// router itself, before its routes are declared; requests matching no route
+// pass through.
+func (s *LoadShedder) Middleware(router chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.flags.Get().SheddingEnabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			route, ok := matchRoute(router, r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			prio, ok := s.priorities[route]
+			if !ok {
+				prio = PriorityNormal
+			}
+			limiter := s.route(route)
+			if !limiter.acquire(1) {
+				s.reject(w, route, prio)
+				return
+			}
+			if !s.global.acquire(prio.share()) {
+				limiter.cancel()
+				s.reject(w, route, prio)
+				return
+			}
+			s.observe(route, limiter)
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				rtt := time.Since(start)
+				failed := rec.status >= 500
+				tolerance := s.flags.Get().SheddingLatencyTolerance
+				limiter.release(rtt, failed, tolerance)
+				s.global.release(rtt, failed, tolerance)
+				s.observe(route, limiter)
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func (s *LoadShedder) route(name string) *aimdLimiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.routes[name]
+	if !ok {
+		l = newAIMDLimiter(routeInitialLimit, routeMaxLimit)
+		s.routes[name] = l
+	}
+	return l
+}
+
+func (s *LoadShedder) reject(w http.ResponseWriter, route string, prio Priority) {
+	s.shed.With(route, prio.String()).Inc()
+	w.Header().Set("Retry-After", "1")
+	encodeError(w, "server overloaded, retry later", http.StatusServiceUnavailable)
+}
+
+func (s *LoadShedder) observe(route string, l *aimdLimiter) {
+	limit, inflight := l.state()
+	s.limits.With(route).Set(limit)
+	s.inflight.With(route).Set(inflight)
+	limit, inflight = s.global.state()
+	s.limits.With("global").Set(limit)
+	s.inflight.With("global").Set(inflight)
+}
+
+// aimdLimiter is an additive-increase/multiplicative-decrease concurrency
+// limit. A request is overloaded when it fails with a 5xx or takes longer
+// than tolerance times the limiter's baseline latency, a slow moving average
+// that follows the route's normal latency.
+type aimdLimiter struct {
+	mu           sync.Mutex
+	limit        float64
+	max          float64
+	inflight     int
+	baseline     time.Duration
+	lastDecrease time.Time
+}
+
+func newAIMDLimiter(initial, max int) *aimdLimiter {
+	return &aimdLimiter{limit: float64(initial), max: float64(max)}
+}
+
+// acquire takes a slot if fewer than share of the limit are in flight.
+func (l *aimdLimiter) acquire(share float64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if float64(l.inflight) >= math.Max(1, math.Floor(l.limit*share)) {
+		return false
+	}
+	l.inflight++
+	return true
+}
+
+// cancel returns a slot without a latency sample.
+func (l *aimdLimiter) cancel() {
+	l.mu.Lock()
+	l.inflight--
+	l.mu.Unlock()
+}
+
+// release returns a slot and adapts the limit to the request's outcome.
+func (l *aimdLimiter) release(rtt time.Duration, failed bool, tolerance float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	inflight := l.inflight
+	l.inflight--
+	if l.baseline == 0 {
+		l.baseline = rtt
+	}
+	overloaded := failed || float64(rtt) > tolerance*float64(l.baseline)
+	if !failed {
+		l.baseline += (rtt - l.baseline) / 20
+	}
+	now := time.Now()
+	switch {
+	case overloaded:
+		// Back off at most once per baseline latency, so a burst of slow
+		// responses from the same overload counts once.
+		if now.Sub(l.lastDecrease) >= l.baseline {
+			l.limit = math.Max(minLimit, l.limit*0.9)
+			l.lastDecrease = now
+		}
+	case float64(inflight)*2 >= l.limit:
+		// Only grow while the limit is actually being used.
+		l.limit = math.Min(l.max, l.limit+1)
+	}
+}
+
+func (l *aimdLimiter) state() (limit, inflight float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return math.Floor(l.limit), float64(l.inflight)
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Flush sends buffered data to the client, when the underlying writer can.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+// NewTariffAdjustmentController creates a controller for tariff adjustments
+// addressed by transaction, and for bulk adjustments.
+func NewTariffAdjustmentController(s usecases.AccountService, bulk usecases.BulkAdjustmentService) *TariffAdjustmentController {
+	return &TariffAdjustmentController{usecase: s, bulk: bulk}
+}
+
+type TariffAdjustmentController struct {
+	usecase usecases.AccountService
+	bulk    usecases.BulkAdjustmentService
+}
+
+// Routes registers tariff adjustment routes on r.
+func (c *TariffAdjustmentController) Routes(r chi.Router) {
+	r.Get("/tariff-adjustments/{transaction_id}", c.get)
+	r.Delete("/tariff-adjustments/{transaction_id}", c.cancel)
+	r.Post("/tariff-adjustments/{transaction_id}/revert", c.revert)
+	r.Post("/tariff-adjustments/bulk", c.submitBulk)
+	r.Get("/tariff-adjustments/bulk/{id}", c.getBulk)
+	r.Post("/tariff-adjustments/bulk/{id}/retry", c.retryBulk)
+}
+
+func (c *TariffAdjustmentController) get(w http.ResponseWriter, r *http.Request) {
+	st, err := c.usecase.GetTariffAdjustment(r.Context(), chi.URLParam(r, "transaction_id"))
+	if err != nil {
+		encodeLifecycleError(w, r, err)
+		return
+	}
+	encodeJSON(w, st, http.StatusOK)
+}
+
+func (c *TariffAdjustmentController) cancel(w http.ResponseWriter, r *http.Request) {
+	st, err := c.usecase.CancelTariffAdjustment(r.Context(), chi.URLParam(r, "transaction_id"))
+	if err != nil {
+		encodeLifecycleError(w, r, err)
+		return
+	}
+	encodeJSON(w, st, http.StatusOK)
+}
+
+// revert answers 202 with the compensating adjustment, which goes through
+// the approval flow like any other.
+func (c *TariffAdjustmentController) revert(w http.ResponseWriter, r *http.Request) {
+	st, err := c.usecase.RevertTariffAdjustment(r.Context(), chi.URLParam(r, "transaction_id"))
+	if err != nil {
+		encodeLifecycleError(w, r, err)
+		return
+	}
+	encodeJSON(w, st, http.StatusAccepted)
+}
+
+func encodeLifecycleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		encodeError(w, "tariff adjustment not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrNotCancellable), errors.Is(err, domain.ErrNotRevertible):
+		encodeError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrBackendCall), errors.Is(err, domain.ErrInvalidAdjustment):
+		encodeSendError(w, r, err)
+	default:
+		slog.ErrorContext(r.Context(), "tariff adjustment request failed", "err", err)
+		encodeError(w, "tariff adjustment request failed", http.StatusInternalServerError)
+	}
+}
+
+func (c *TariffAdjustmentController) submitBulk(w http.ResponseWriter, r *http.Request) {
+	var req domain.BulkAdjustmentRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		encodeError(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun, err := parseBoolParam(r.URL.Query(), "dry_run")
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dryRun {
+		c.previewBulk(w, r, req)
+		return
+	}
+	job, err := c.bulk.Submit(r.Context(), jobOwner(r), req)
+	if err != nil {
+		c.encodeBulkError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", bulkJobURL(job.ID))
+	encodeJSON(w, job, http.StatusAccepted)
+}
+
+// previewBulk answers a dry run: what req would do, projected on the report
+// selected by the report query parameters.
+func (c *TariffAdjustmentController) previewBulk(w http.ResponseWriter, r *http.Request, req domain.BulkAdjustmentRequest) {
+	params, err := parseReportParams(r.URL.Query())
+	if err != nil {
+		encodeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	preview, err := c.bulk.Preview(r.Context(), req, params)
+	if err != nil {
+		c.encodeBulkError(w, r, err)
+		return
+	}
+	encodeJSON(w, preview, http.StatusOK)
+}
+
+// getBulk returns a job; ?status=failed (or any item status) lists only the
+// items with that status.
+func (c *TariffAdjustmentController) getBulk(w http.ResponseWriter, r *http.Request) {
+	job, err := c.bulk.Get(r.Context(), clientID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		c.encodeBulkError(w, r, err)
+		return
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		items := []domain.BulkAdjustmentItem{}
+		for _, it := range job.Items {
+			if it.Status == status {
+				items = append(items, it)
+			}
+		}
+		job.Items = items
+	}
+	encodeJSON(w, job, http.StatusOK)
+}
+
+func (c *TariffAdjustmentController) retryBulk(w http.ResponseWriter, r *http.Request) {
+	job, err := c.bulk.RetryFailed(r.Context(), jobOwner(r), chi.URLParam(r, "id"))
+	if err != nil {
+		c.encodeBulkError(w, r, err)
+		return
+	}
+	encodeJSON(w, job, http.StatusAccepted)
+}
+
+func (c *TariffAdjustmentController) encodeBulkError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidBulkRequest), errors.Is(err, domain.ErrInvalidReportParams):
+		encodeError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrNotFound):
+		encodeError(w, "bulk adjustment job not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrBulkJobRunning):
+		encodeError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrTooManyJobs):
+		w.Header().Set("Retry-After", "10")
+		encodeError(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		slog.ErrorContext(r.Context(), "bulk adjustment request failed", "err", err)
+		encodeError(w, "bulk adjustment request failed", http.StatusInternalServerError)
+	}
+}
+
+func tariffAdjustmentURL(transactionID string) string {
+	return "/v1/tariff-adjustments/" + transactionID
+}
+
+func bulkJobURL(id string) string {
+	return "/v1/tariff-adjustments/bulk/" + id
+}
@@ -0,0 +1,331 @@
+package httpclient
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sre/internal/metrics"
+)
+
+// ResponseCache is a private HTTP cache for GET responses. It honors
+// Cache-Control (max-age, no-cache, no-store) and Vary, stores ETag and
+// Last-Modified validators, revalidates stale entries with If-None-Match /
+// If-Modified-Since and serves 304 answers from the stored body.
+//
+// Register its Middleware outermost so fresh hits skip retries and breakers.
+// JSON values decoded from a cached body are reused while the entry is
+// unchanged, so callers must treat them as read-only.
+type ResponseCache struct {
+	maxEntries    int
+	maxEntryBytes int64
+	maxBytes      int64
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	// bytes is the total size of the cached bodies.
+	bytes int64
+
+	hits, revalidated, misses atomic.Int64
+}
+
+type cacheEntry struct {
+	key        string
+	status     int
+	header     http.Header
+	body       []byte
+	vary       map[string]string
+	storedAt   time.Time
+	freshUntil time.Time
+
+	decodedMu sync.Mutex
+	decoded   map[string]interface{}
+}
+
+// NewResponseCache creates a cache holding at most maxEntries responses of at
+// most maxEntryBytes each, and at most maxBytes of bodies in all. The least
+// recently used entries are evicted first.
+func NewResponseCache(maxEntries int, maxEntryBytes, maxBytes int64) *ResponseCache {
+	return &ResponseCache{
+		maxEntries:    maxEntries,
+		maxEntryBytes: min(maxEntryBytes, maxBytes),
+		maxBytes:      maxBytes,
+		lru:           list.New(),
+		entries:       make(map[string]*list.Element),
+	}
+}
+
+type noCacheOpt struct{}
+
+func (noCacheOpt) apply(c *requestConfig) { c.noCache = true }
+
+// WithoutCache sends a request past the ResponseCache, neither served nor
+// stored by it. StreamJSON uses it, so that streamed bodies are not buffered.
+func WithoutCache() RequestOption { return noCacheOpt{} }
+
+// Middleware returns the caching middleware.
+func (c *ResponseCache) Middleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(r *Request) (*http.Response, error) {
+			if r.HTTP.Method != http.MethodGet || r.HTTP.Header.Get("Range") != "" || r.Config.NoCache {
+				return next(r)
+			}
+			key := r.HTTP.URL.String()
+			e := c.lookup(key, r.HTTP)
+			now := time.Now()
+			if e != nil && now.Before(e.freshUntil) {
+				c.hits.Add(1)
+				return e.response(r.HTTP, "HIT"), nil
+			}
+			if e != nil {
+				r = conditional(r, e)
+			}
+			res, err := next(r)
+			if err != nil {
+				return nil, err
+			}
+			if e != nil && res.StatusCode == http.StatusNotModified {
+				_, _ = io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+				c.revalidated.Add(1)
+				e = c.refresh(e, res.Header, now)
+				return e.response(r.HTTP, "REVALIDATED"), nil
+			}
+			c.misses.Add(1)
+			return c.store(key, r.HTTP, res, now)
+		}
+	}
+}
+
+// RegisterMetrics exposes hit, revalidation and miss counters on reg.
+func (c *ResponseCache) RegisterMetrics(reg *metrics.Registry) {
+	load := func(v *atomic.Int64) func() float64 {
+		return func() float64 { return float64(v.Load()) }
+	}
+	reg.CounterFunc("sre_http_client_cache_hits_total", "Backend GETs served from a fresh cache entry.", load(&c.hits))
+	reg.CounterFunc("sre_http_client_cache_revalidated_total", "Backend GETs answered 304 and served from cache.", load(&c.revalidated))
+	reg.CounterFunc("sre_http_client_cache_misses_total", "Backend GETs that transferred a full response.", load(&c.misses))
+	reg.GaugeFunc("sre_http_client_cache_bytes", "Size of the response bodies in the cache.", func() float64 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return float64(c.bytes)
+	})
+}
+
+func (c *ResponseCache) lookup(key string, req *http.Request) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*cacheEntry)
+	for h, v := range e.vary {
+		if req.Header.Get(h) != v {
+			return nil
+		}
+	}
+	c.lru.MoveToFront(el)
+	return e
+}
+
+// refresh replaces e with a copy whose headers and freshness come from a 304.
+func (c *ResponseCache) refresh(e *cacheEntry, h http.Header, now time.Time) *cacheEntry {
+	header := e.header.Clone()
+	for _, k := range []string{"Cache-Control", "Expires", "Etag", "Last-Modified", "Date"} {
+		if v := h.Values(k); len(v) > 0 {
+			header[k] = v
+		}
+	}
+	ne := &cacheEntry{
+		key:        e.key,
+		status:     e.status,
+		header:     header,
+		body:       e.body,
+		vary:       e.vary,
+		storedAt:   now,
+		freshUntil: now.Add(freshness(header)),
+	}
+	if header.Get("ETag") == e.header.Get("ETag") {
+		e.decodedMu.Lock()
+		ne.decoded = maps.Clone(e.decoded)
+		e.decodedMu.Unlock()
+	}
+	c.put(ne)
+	return ne
+}
+
+// store caches res when it is cacheable and returns a response whose body
+// can still be read by the caller.
+func (c *ResponseCache) store(key string, req *http.Request, res *http.Response, now time.Time) (*http.Response, error) {
+	if res.StatusCode != http.StatusOK || !storable(res.Header) {
+		return res, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, c.maxEntryBytes+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > c.maxEntryBytes {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	res.Body.Close()
+	vary := make(map[string]string)
+	for _, v := range res.Header.Values("Vary") {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				vary[h] = req.Header.Get(h)
+			}
+		}
+	}
+	if _, all := vary["*"]; all {
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		return res, nil
+	}
+	e := &cacheEntry{
+		key:        key,
+		status:     res.StatusCode,
+		header:     res.Header.Clone(),
+		body:       body,
+		vary:       vary,
+		storedAt:   now,
+		freshUntil: now.Add(freshness(res.Header)),
+	}
+	c.put(e)
+	return e.response(req, "MISS"), nil
+}
+
+func (c *ResponseCache) put(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		c.bytes -= int64(len(el.Value.(*cacheEntry).body))
+		el.Value = e
+		c.lru.MoveToFront(el)
+	} else {
+		c.entries[e.key] = c.lru.PushFront(e)
+	}
+	c.bytes += int64(len(e.body))
+	for c.lru.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		old := c.lru.Back()
+		c.lru.Remove(old)
+		oe := old.Value.(*cacheEntry)
+		delete(c.entries, oe.key)
+		c.bytes -= int64(len(oe.body))
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request, outcome string) *http.Response {
+	h := e.header.Clone()
+	h.Set("X-Cache", outcome)
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          &cachedBody{Reader: bytes.NewReader(e.body), entry: e},
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// cachedBody lets the JSON helpers reuse a value already decoded from the
+// same cache entry instead of decoding the bytes again.
+type cachedBody struct {
+	*bytes.Reader
+	entry *cacheEntry
+}
+
+func (b *cachedBody) Close() error { return nil }
+
+func (b *cachedBody) decodedValue(typeKey string) (interface{}, bool) {
+	b.entry.decodedMu.Lock()
+	defer b.entry.decodedMu.Unlock()
+	v, ok := b.entry.decoded[typeKey]
+	return v, ok
+}
+
+func (b *cachedBody) storeDecoded(typeKey string, v interface{}) {
+	b.entry.decodedMu.Lock()
+	defer b.entry.decodedMu.Unlock()
+	if b.entry.decoded == nil {
+		b.entry.decoded = make(map[string]interface{})
+	}
+	b.entry.decoded[typeKey] = v
+}
+
+func conditional(r *Request, e *cacheEntry) *Request {
+	req := r.HTTP.Clone(r.HTTP.Context())
+	if etag := e.header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lm := e.header.Get("Last-Modified"); lm != "" {
+		req.Header.Set("If-Modified-Since", lm)
+	}
+	cp := *r
+	cp.HTTP = req
+	return &cp
+}
+
+// storable reports whether a 200 response may be cached: not no-store, and
+// either fresh for a while or carrying a validator to revalidate with.
+func storable(h http.Header) bool {
+	cc := parseCacheControl(h)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	return freshness(h) > 0 || h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// freshness returns how long a response may be served without revalidation.
+func freshness(h http.Header) time.Duration {
+	cc := parseCacheControl(h)
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if v, ok := cc["max-age"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+		return 0
+	}
+	if exp := h.Get("Expires"); exp != "" {
+		if t, err := http.ParseTime(exp); err == nil {
+			date := time.Now()
+			if d, err := http.ParseTime(h.Get("Date")); err == nil {
+				date = d
+			}
+			return t.Sub(date)
+		}
+	}
+	return 0
+}
+
+func parseCacheControl(h http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			k, val, _ := strings.Cut(d, "=")
+			cc[strings.ToLower(k)] = strings.Trim(val, `"`)
+		}
+	}
+	return cc
+}
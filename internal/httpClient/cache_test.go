@@ -0,0 +1,220 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// origin answers GETs with body and header, or 304 when the request's
+// validators match them, and keeps the requests it got.
+type origin struct {
+	header   http.Header
+	body     string
+	requests []*http.Request
+}
+
+func (o *origin) roundTrip(r *Request) (*http.Response, error) {
+	o.requests = append(o.requests, r.HTTP)
+	status, body := http.StatusOK, o.body
+	inm, ims := r.HTTP.Header.Get("If-None-Match"), r.HTTP.Header.Get("If-Modified-Since")
+	if (inm != "" && inm == o.header.Get("ETag")) || (ims != "" && ims == o.header.Get("Last-Modified")) {
+		status, body = http.StatusNotModified, ""
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     o.header.Clone(),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r.HTTP,
+	}, nil
+}
+
+func get(t *testing.T, rt RoundTripFunc, accept string, opts ...RequestOption) (outcome, body string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://backend/v1/accounts/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	cfg := &requestConfig{}
+	for _, o := range opts {
+		o.apply(cfg)
+	}
+	res, err := rt(&Request{HTTP: req, Pattern: "/v1/accounts/{id}", Attempt: 1, Config: RequestInfo{NoCache: cfg.noCache}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Header.Get("X-Cache"), string(b)
+}
+
+func TestResponseCache(t *testing.T) {
+	type step struct {
+		// change, when set, updates the origin before the request.
+		change  func(o *origin)
+		accept  string
+		opts    []RequestOption
+		outcome string
+		body    string
+		// validator is the conditional header the origin must get, if any.
+		validator string
+	}
+	tests := []struct {
+		name     string
+		header   http.Header
+		body     string
+		maxEntry int64
+		steps    []step
+		requests int
+	}{
+		{
+			name:   "fresh entry is a hit",
+			header: http.Header{"Cache-Control": {"max-age=60"}},
+			body:   `{"id":"1"}`,
+			steps: []step{
+				{outcome: "MISS", body: `{"id":"1"}`},
+				{outcome: "HIT", body: `{"id":"1"}`},
+			},
+			requests: 1,
+		},
+		{
+			name:   "unchanged ETag revalidates",
+			header: http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}},
+			body:   `{"id":"1"}`,
+			steps: []step{
+				{outcome: "MISS", body: `{"id":"1"}`},
+				{outcome: "REVALIDATED", body: `{"id":"1"}`, validator: `If-None-Match: "v1"`},
+				{outcome: "REVALIDATED", body: `{"id":"1"}`, validator: `If-None-Match: "v1"`},
+			},
+			requests: 3,
+		},
+		{
+			name:   "changed ETag transfers the new body",
+			header: http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}},
+			body:   `{"fee":1}`,
+			steps: []step{
+				{outcome: "MISS", body: `{"fee":1}`},
+				{
+					change: func(o *origin) {
+						o.header.Set("ETag", `"v2"`)
+						o.body = `{"fee":2}`
+					},
+					outcome: "MISS", body: `{"fee":2}`, validator: `If-None-Match: "v1"`,
+				},
+				{outcome: "REVALIDATED", body: `{"fee":2}`, validator: `If-None-Match: "v2"`},
+			},
+			requests: 3,
+		},
+		{
+			name:   "Last-Modified revalidates",
+			header: http.Header{"Last-Modified": {"Mon, 19 Oct 2026 10:00:00 GMT"}},
+			body:   `[]`,
+			steps: []step{
+				{outcome: "MISS", body: `[]`},
+				{outcome: "REVALIDATED", body: `[]`, validator: "If-Modified-Since: Mon, 19 Oct 2026 10:00:00 GMT"},
+			},
+			requests: 2,
+		},
+		{
+			name:   "no-store is not cached",
+			header: http.Header{"Cache-Control": {"no-store"}, "Etag": {`"v1"`}},
+			body:   `{}`,
+			steps: []step{
+				{body: `{}`},
+				{body: `{}`},
+			},
+			requests: 2,
+		},
+		{
+			name:   "Vary keeps representations apart",
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}},
+			body:   `{}`,
+			steps: []step{
+				{accept: "application/json", outcome: "MISS", body: `{}`},
+				{accept: "application/json", outcome: "HIT", body: `{}`},
+				{accept: "text/csv", outcome: "MISS", body: `{}`},
+			},
+			requests: 2,
+		},
+		{
+			name:   "WithoutCache bypasses the cache",
+			header: http.Header{"Cache-Control": {"max-age=60"}},
+			body:   `{}`,
+			steps: []step{
+				{opts: []RequestOption{WithoutCache()}, body: `{}`},
+				{opts: []RequestOption{WithoutCache()}, body: `{}`},
+			},
+			requests: 2,
+		},
+		{
+			name:     "entry over the size limit is passed through whole",
+			header:   http.Header{"Cache-Control": {"max-age=60"}},
+			body:     `["0123456789"]`,
+			maxEntry: 8,
+			steps: []step{
+				{body: `["0123456789"]`},
+				{body: `["0123456789"]`},
+			},
+			requests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &origin{header: tt.header, body: tt.body}
+			maxEntry := tt.maxEntry
+			if maxEntry == 0 {
+				maxEntry = 1 << 20
+			}
+			rt := NewResponseCache(16, maxEntry, 1<<20).Middleware()(o.roundTrip)
+			for i, s := range tt.steps {
+				if s.change != nil {
+					s.change(o)
+				}
+				outcome, body := get(t, rt, s.accept, s.opts...)
+				if outcome != s.outcome || body != s.body {
+					t.Errorf("step %d: got %q %s, want %q %s", i, outcome, body, s.outcome, s.body)
+				}
+				last := o.requests[len(o.requests)-1]
+				if s.validator != "" {
+					k, v, _ := strings.Cut(s.validator, ": ")
+					if got := last.Header.Get(k); got != v {
+						t.Errorf("step %d: origin got %s %q, want %q", i, k, got, v)
+					}
+				}
+			}
+			if len(o.requests) != tt.requests {
+				t.Errorf("origin got %d requests, want %d", len(o.requests), tt.requests)
+			}
+		})
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	c := NewResponseCache(16, 1<<10, 10)
+	o := &origin{header: http.Header{"Cache-Control": {"max-age=60"}}, body: "123456"}
+	rt := c.Middleware()(o.roundTrip)
+	if outcome, _ := get(t, rt, ""); outcome != "MISS" {
+		t.Fatalf("first GET: %q, want MISS", outcome)
+	}
+	// A second 6-byte body under another key goes over maxBytes and evicts
+	// the first.
+	req, _ := http.NewRequest(http.MethodGet, "http://backend/v1/accounts/2", nil)
+	res, err := rt(&Request{HTTP: req})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if c.bytes != 6 {
+		t.Errorf("cache holds %d bytes, want 6", c.bytes)
+	}
+	if outcome, _ := get(t, rt, ""); outcome != "MISS" {
+		t.Errorf("GET of the evicted entry: %q, want MISS", outcome)
+	}
+}
@@ -5,21 +5,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	dialTimeout    = 1 * time.Second
 	requestTimeout = 30 * time.Second
 	poolName       = "fintech_sre_client"
 )
 
 // EndpointFactory builds HTTP endpoints for a base URL.
 type EndpointFactory interface {
-	Build(pattern string) Endpoint
+	Build(pattern string, opts ...EndpointOption) Endpoint
+}
+
+// EndpointOption configures a single endpoint built by an EndpointFactory.
+type EndpointOption func(*endpointConfig)
+
+type endpointConfig struct {
+	timeout time.Duration
+}
+
+// WithTimeout overrides the factory's request timeout for one endpoint. It
+// covers the whole exchange, including reading the response body.
+func WithTimeout(d time.Duration) EndpointOption {
+	return func(c *endpointConfig) { c.timeout = d }
 }
 
 // Endpoint performs HTTP requests. Path params like {id} are replaced via WithParam.
@@ -27,9 +41,14 @@ type Endpoint interface {
 	Get(ctx context.Context, opts ...RequestOption) (*http.Response, error)
 	Post(ctx context.Context, opts ...RequestOption) (*http.Response, error)
 	Patch(ctx context.Context, opts ...RequestOption) (*http.Response, error)
+	Put(ctx context.Context, opts ...RequestOption) (*http.Response, error)
+	Delete(ctx context.Context, opts ...RequestOption) (*http.Response, error)
+	Head(ctx context.Context, opts ...RequestOption) (*http.Response, error)
+	// Pattern returns the pattern the endpoint was built from.
+	Pattern() string
 }
 
-// RequestOption configures a request (path params, query, body).
+// RequestOption configures a request (path params, query, headers, body).
 type RequestOption interface {
 	apply(*requestConfig)
 }
@@ -38,6 +57,12 @@ type requestConfig struct {
 	pathParams map[string]string
 	query      url.Values
 	body       interface{}
+	rawBody    io.Reader
+	rawType    string
+	headers    http.Header
+	expect     []int
+	maxBody    int64
+	noCache    bool
 }
 
 type paramOpt struct{ k, v string }
@@ -55,21 +80,76 @@ func WithParam(key, value string) RequestOption { return paramOpt{k: key, v: val
 // WithQuery sets URL query values.
 func WithQuery(v url.Values) RequestOption { return queryOpt{v: v} }
 
-// WithBody sets the JSON body for POST/PATCH.
+type rawBodyOpt struct {
+	r           io.Reader
+	contentType string
+}
+func (o rawBodyOpt) apply(c *requestConfig) { c.rawBody, c.rawType = o.r, o.contentType }
+
+type headerOpt struct{ h http.Header }
+func (o headerOpt) apply(c *requestConfig) {
+	for k, vs := range o.h {
+		for _, v := range vs {
+			c.headers.Add(k, v)
+		}
+	}
+}
+
+// WithBody sets a body encoded as JSON, sent with Content-Type: application/json.
 func WithBody(v interface{}) RequestOption { return bodyOpt{v: v} }
 
+// WithRawBody sends r as the body with the given Content-Type. It takes
+// precedence over WithBody. Requests are only retried when r is a
+// *bytes.Buffer, *bytes.Reader or *strings.Reader.
+func WithRawBody(r io.Reader, contentType string) RequestOption {
+	return rawBodyOpt{r: r, contentType: contentType}
+}
+
+// WithHeader adds a request header (e.g. "Idempotency-Key"). Headers set this
+// way override the defaults, including Accept and Content-Type.
+func WithHeader(key, value string) RequestOption {
+	h := make(http.Header)
+	h.Add(key, value)
+	return headerOpt{h: h}
+}
+
+// WithHeaders adds every header in h.
+func WithHeaders(h http.Header) RequestOption { return headerOpt{h: h.Clone()} }
+
 var _ EndpointFactory = (*DefaultEndpointFactory)(nil)
 
+// FactoryOption configures a DefaultEndpointFactory.
+type FactoryOption func(*factoryConfig)
+
+type factoryConfig struct {
+	transport      TransportConfig
+	requestTimeout time.Duration
+}
+
+// WithTransportConfig replaces DefaultTransportConfig for the factory's connection pool.
+func WithTransportConfig(c TransportConfig) FactoryOption {
+	return func(fc *factoryConfig) { fc.transport = c }
+}
+
+// WithRequestTimeout sets the default timeout of every request (30s if unset).
+func WithRequestTimeout(d time.Duration) FactoryOption {
+	return func(fc *factoryConfig) { fc.requestTimeout = d }
+}
+
 // NewEndpointFactory creates a factory for the given base URL.
-func NewEndpointFactory(baseURL string) *DefaultEndpointFactory {
+func NewEndpointFactory(baseURL string, opts ...FactoryOption) *DefaultEndpointFactory {
+	cfg := factoryConfig{transport: DefaultTransportConfig(), requestTimeout: requestTimeout}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	stats := &poolStats{}
 	return &DefaultEndpointFactory{
 		baseURL: strings.TrimSuffix(baseURL, "/"),
 		client: &http.Client{
-			Timeout: requestTimeout,
-			Transport: &http.Transport{
-				IdleConnTimeout: dialTimeout,
-			},
+			Timeout:   cfg.requestTimeout,
+			Transport: cfg.transport.build(stats),
 		},
+		stats: stats,
 	}
 }
 
@@ -77,25 +157,61 @@ func NewEndpointFactory(baseURL string) *DefaultEndpointFactory {
 type DefaultEndpointFactory struct {
 	baseURL string
 	client  *http.Client
+	stats   *poolStats
+
+	mu          sync.RWMutex
+	middlewares []scopedMiddleware
 }
 
 // Build returns an Endpoint for baseURL + pattern. Pattern may contain placeholders like {id}.
-func (f *DefaultEndpointFactory) Build(pattern string) Endpoint {
+func (f *DefaultEndpointFactory) Build(pattern string, opts ...EndpointOption) Endpoint {
+	var cfg endpointConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+	client := f.client
+	if cfg.timeout > 0 {
+		c := *f.client
+		c.Timeout = cfg.timeout
+		client = &c
+	}
 	return &defaultEndpoint{
+		factory: f,
 		baseURL: f.baseURL,
-		pattern: strings.TrimPrefix(pattern, "/"),
-		client:  f.client,
+		pattern: "/" + strings.TrimPrefix(pattern, "/"),
+		client:  client,
+		stats:   f.stats,
 	}
 }
 
+// PoolStats returns a snapshot of the connection pool counters.
+func (f *DefaultEndpointFactory) PoolStats() PoolStats {
+	return f.stats.snapshot()
+}
+
 type defaultEndpoint struct {
+	factory *DefaultEndpointFactory
 	baseURL string
 	pattern string
 	client  *http.Client
+	stats   *poolStats
+}
+
+// send is the innermost RoundTripFunc: it performs the request, tracking
+// connection pool usage until the body is released.
+func (e *defaultEndpoint) send(r *Request) (*http.Response, error) {
+	ctx, release := e.stats.trace(r.HTTP.Context())
+	res, err := e.client.Do(r.HTTP.WithContext(ctx))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	res.Body = &bodyReleaser{body: res.Body, release: release}
+	return res, nil
 }
 
 func (e *defaultEndpoint) urlAndConfig(opts []RequestOption) (string, *requestConfig, error) {
-	cfg := &requestConfig{pathParams: make(map[string]string)}
+	cfg := &requestConfig{pathParams: make(map[string]string), headers: make(http.Header)}
 	for _, o := range opts {
 		o.apply(cfg)
 	}
@@ -103,72 +219,95 @@ func (e *defaultEndpoint) urlAndConfig(opts []RequestOption) (string, *requestCo
 	for k, v := range cfg.pathParams {
 		path = strings.ReplaceAll(path, "{"+k+"}", url.PathEscape(v))
 	}
-	u := e.baseURL + "/" + path
+	u := e.baseURL + path
 	if cfg.query != nil && len(cfg.query) > 0 {
 		u += "?" + cfg.query.Encode()
 	}
 	return u, cfg, nil
 }
 
+func (e *defaultEndpoint) Pattern() string { return e.pattern }
+
 func (e *defaultEndpoint) Get(ctx context.Context, opts ...RequestOption) (*http.Response, error) {
-	u, _, err := e.urlAndConfig(opts)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	return e.client.Do(req)
+	return e.do(ctx, http.MethodGet, opts)
 }
 
 func (e *defaultEndpoint) Post(ctx context.Context, opts ...RequestOption) (*http.Response, error) {
-	u, cfg, err := e.urlAndConfig(opts)
-	if err != nil {
-		return nil, err
-	}
-	var body []byte
-	if cfg.body != nil {
-		body, err = json.Marshal(cfg.body)
-		if err != nil {
-			return nil, err
-		}
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	return e.client.Do(req)
+	return e.do(ctx, http.MethodPost, opts)
 }
 
 func (e *defaultEndpoint) Patch(ctx context.Context, opts ...RequestOption) (*http.Response, error) {
+	return e.do(ctx, http.MethodPatch, opts)
+}
+
+func (e *defaultEndpoint) Put(ctx context.Context, opts ...RequestOption) (*http.Response, error) {
+	return e.do(ctx, http.MethodPut, opts)
+}
+
+func (e *defaultEndpoint) Delete(ctx context.Context, opts ...RequestOption) (*http.Response, error) {
+	return e.do(ctx, http.MethodDelete, opts)
+}
+
+func (e *defaultEndpoint) Head(ctx context.Context, opts ...RequestOption) (*http.Response, error) {
+	return e.do(ctx, http.MethodHead, opts)
+}
+
+// do builds the request for method and runs it through the factory's middleware chain.
+func (e *defaultEndpoint) do(ctx context.Context, method string, opts []RequestOption) (*http.Response, error) {
 	u, cfg, err := e.urlAndConfig(opts)
 	if err != nil {
 		return nil, err
 	}
-	var body []byte
-	if cfg.body != nil {
-		body, err = json.Marshal(cfg.body)
+	var body io.Reader
+	contentType := ""
+	switch {
+	case cfg.rawBody != nil:
+		body, contentType = cfg.rawBody, cfg.rawType
+	case cfg.body != nil:
+		b, err := json.Marshal(cfg.body)
 		if err != nil {
 			return nil, err
 		}
+		body, contentType = bytes.NewReader(b), "application/json"
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, u, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	return e.client.Do(req)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, vs := range cfg.headers {
+		req.Header[k] = vs
+	}
+	rt := e.factory.chain(e.pattern, e.send)
+	return rt(&Request{
+		HTTP:    req,
+		Pattern: e.pattern,
+		Attempt: 1,
+		Config: RequestInfo{
+			PathParams: cfg.pathParams,
+			Query:      cfg.query,
+			Body:       cfg.body,
+			Headers:    cfg.headers,
+			NoCache:    cfg.noCache,
+		},
+	})
 }
 
-// HTTPError represents a non-2xx response.
+// HTTPError represents a response with an unexpected status. Body holds at
+// most the first 512 bytes of the response body.
 type HTTPError struct {
 	StatusCode int
+	Method     string
+	Pattern    string
 	Body       string
 }
 
 func (e *HTTPError) Error() string {
-	return fmt.Sprintf("http %d: %s", e.StatusCode, e.Body)
+	if e.Method == "" {
+		return fmt.Sprintf("http %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("%s %s: http %d: %s", e.Method, e.Pattern, e.StatusCode, e.Body)
 }
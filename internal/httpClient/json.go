@@ -0,0 +1,201 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	defaultMaxBodyBytes = 8 << 20
+	maxErrorBodyBytes   = 512
+)
+
+// Empty is the response type for calls whose response body is ignored.
+type Empty struct{}
+
+type expectOpt struct{ codes []int }
+
+func (o expectOpt) apply(c *requestConfig) { c.expect = o.codes }
+
+type maxBodyOpt struct{ n int64 }
+
+func (o maxBodyOpt) apply(c *requestConfig) { c.maxBody = o.n }
+
+// ExpectStatus declares the status codes the JSON helpers accept. Any other
+// status yields an *HTTPError. Without it, any 2xx is accepted.
+func ExpectStatus(codes ...int) RequestOption { return expectOpt{codes: codes} }
+
+// WithMaxBodyBytes caps the response body the JSON helpers read (8 MiB by default).
+func WithMaxBodyBytes(n int64) RequestOption { return maxBodyOpt{n: n} }
+
+// GetJSON performs a GET on e and decodes the JSON response into T.
+func GetJSON[T any](ctx context.Context, e Endpoint, opts ...RequestOption) (T, error) {
+	res, err := e.Get(ctx, opts...)
+	return decodeJSON[T](e, res, err, opts)
+}
+
+// PostJSON POSTs body as JSON to e and decodes the JSON response into Resp.
+func PostJSON[Req, Resp any](ctx context.Context, e Endpoint, body Req, opts ...RequestOption) (Resp, error) {
+	opts = append(opts, WithBody(body))
+	res, err := e.Post(ctx, opts...)
+	return decodeJSON[Resp](e, res, err, opts)
+}
+
+// PatchJSON PATCHes e with body as JSON and decodes the JSON response into Resp.
+func PatchJSON[Req, Resp any](ctx context.Context, e Endpoint, body Req, opts ...RequestOption) (Resp, error) {
+	opts = append(opts, WithBody(body))
+	res, err := e.Patch(ctx, opts...)
+	return decodeJSON[Resp](e, res, err, opts)
+}
+
+// PutJSON PUTs body as JSON to e and decodes the JSON response into Resp.
+func PutJSON[Req, Resp any](ctx context.Context, e Endpoint, body Req, opts ...RequestOption) (Resp, error) {
+	opts = append(opts, WithBody(body))
+	res, err := e.Put(ctx, opts...)
+	return decodeJSON[Resp](e, res, err, opts)
+}
+
+// DeleteJSON performs a DELETE on e and decodes the JSON response into Resp.
+func DeleteJSON[Resp any](ctx context.Context, e Endpoint, opts ...RequestOption) (Resp, error) {
+	res, err := e.Delete(ctx, opts...)
+	return decodeJSON[Resp](e, res, err, opts)
+}
+
+func decodeJSON[T any](e Endpoint, res *http.Response, err error, opts []RequestOption) (T, error) {
+	var out T
+	if err != nil {
+		return out, err
+	}
+	defer res.Body.Close()
+	cfg := &requestConfig{pathParams: make(map[string]string), headers: make(http.Header), maxBody: defaultMaxBodyBytes}
+	for _, o := range opts {
+		o.apply(cfg)
+	}
+	if err := checkStatus(e, res, cfg.expect); err != nil {
+		return out, err
+	}
+	if _, ok := any(out).(Empty); ok {
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, cfg.maxBody))
+		return out, nil
+	}
+	cached, _ := res.Body.(*cachedBody)
+	typeKey := fmt.Sprintf("%T", out)
+	if cached != nil {
+		if v, ok := cached.decodedValue(typeKey); ok {
+			return v.(T), nil
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, cfg.maxBody+1))
+	if err != nil {
+		return out, err
+	}
+	if int64(len(body)) > cfg.maxBody {
+		return out, fmt.Errorf("%s %s: response body exceeds %d bytes", res.Request.Method, e.Pattern(), cfg.maxBody)
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return out, fmt.Errorf("%s %s: decode response: %w", res.Request.Method, e.Pattern(), err)
+	}
+	if cached != nil {
+		cached.storeDecoded(typeKey, out)
+	}
+	return out, nil
+}
+
+// checkStatus returns an *HTTPError, with the body truncated, when res has a
+// status outside expect (or outside 2xx when expect is empty).
+func checkStatus(e Endpoint, res *http.Response, expect []int) error {
+	ok := res.StatusCode >= 200 && res.StatusCode < 300
+	if len(expect) > 0 {
+		ok = false
+		for _, c := range expect {
+			if c == res.StatusCode {
+				ok = true
+				break
+			}
+		}
+	}
+	if ok {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodyBytes))
+	return &HTTPError{
+		StatusCode: res.StatusCode,
+		Method:     res.Request.Method,
+		Pattern:    e.Pattern(),
+		Body:       string(body),
+	}
+}
+
+// StreamJSON performs a GET on e and decodes a top-level JSON array one
+// element at a time, calling fn for each. It never holds more than one element
+// in memory; an error from fn stops the stream and is returned.
+func StreamJSON[T any](ctx context.Context, e Endpoint, fn func(T) error, opts ...RequestOption) error {
+	opts = append(opts, WithoutCache())
+	res, err := e.Get(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	cfg := &requestConfig{pathParams: make(map[string]string), headers: make(http.Header), maxBody: defaultMaxBodyBytes}
+	for _, o := range opts {
+		o.apply(cfg)
+	}
+	if err := checkStatus(e, res, cfg.expect); err != nil {
+		return err
+	}
+	body := &limitedReader{r: res.Body, n: cfg.maxBody}
+	fail := func(err error) error {
+		if body.exceeded {
+			return fmt.Errorf("%s %s: response body exceeds %d bytes", res.Request.Method, e.Pattern(), cfg.maxBody)
+		}
+		return fmt.Errorf("%s %s: decode response: %w", res.Request.Method, e.Pattern(), err)
+	}
+	dec := json.NewDecoder(body)
+	tok, err := dec.Token()
+	if err != nil {
+		return fail(err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fail(fmt.Errorf("expected JSON array, got %v", tok))
+	}
+	for dec.More() {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return fail(err)
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// limitedReader is like io.LimitedReader but remembers that the limit was hit.
+type limitedReader struct {
+	r        io.Reader
+	n        int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var one [1]byte
+		if n, err := l.r.Read(one[:]); n == 0 && err == io.EOF {
+			return 0, io.EOF
+		}
+		l.exceeded = true
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// Request is an outgoing backend request as seen by a Middleware.
+type Request struct {
+	// HTTP is the request about to be sent. Middlewares may replace it (e.g. with
+	// a clone carrying extra headers) before calling next.
+	HTTP *http.Request
+	// Pattern is the endpoint pattern the request was built from, e.g. "/v1/accounts/{id}".
+	Pattern string
+	// Attempt is 1 for the first try and is incremented by the Retry middleware.
+	Attempt int
+	// Config is the decoded request configuration from the RequestOptions.
+	Config RequestInfo
+}
+
+// RequestInfo exposes the RequestOptions a request was built with.
+type RequestInfo struct {
+	PathParams map[string]string
+	Query      url.Values
+	Body       interface{}
+	Headers    http.Header
+	// NoCache is set by WithoutCache.
+	NoCache bool
+}
+
+// RoundTripFunc sends a Request and returns its response.
+type RoundTripFunc func(*Request) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc with a cross-cutting concern.
+//
+// Ordering contract: factory-wide middlewares (Use) wrap pattern-scoped ones
+// (UseFor); within each group the first registered is the outermost. So for
+// Use(a, b) and UseFor(p, c), a request matching p runs a → b → c → network.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+type scopedMiddleware struct {
+	pattern string // empty means every endpoint
+	mw      Middleware
+}
+
+// Use registers middlewares for every endpoint of the factory, including
+// endpoints built before the call.
+func (f *DefaultEndpointFactory) Use(mw ...Middleware) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range mw {
+		f.middlewares = append(f.middlewares, scopedMiddleware{mw: m})
+	}
+}
+
+// UseFor registers middlewares for endpoints whose pattern matches glob, using
+// path.Match syntax against the pattern passed to Build (e.g. "/v1/accounts/*").
+func (f *DefaultEndpointFactory) UseFor(glob string, mw ...Middleware) {
+	if _, err := path.Match(glob, ""); err != nil {
+		panic("httpclient: bad middleware pattern " + glob + ": " + err.Error())
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range mw {
+		f.middlewares = append(f.middlewares, scopedMiddleware{pattern: glob, mw: m})
+	}
+}
+
+// chain wraps final with the middlewares that apply to pattern.
+func (f *DefaultEndpointFactory) chain(pattern string, final RoundTripFunc) RoundTripFunc {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var global, scoped []Middleware
+	for _, m := range f.middlewares {
+		if m.pattern == "" {
+			global = append(global, m.mw)
+		} else if ok, _ := path.Match(m.pattern, pattern); ok {
+			scoped = append(scoped, m.mw)
+		}
+	}
+	rt := final
+	all := append(global, scoped...)
+	for i := len(all) - 1; i >= 0; i-- {
+		rt = all[i](rt)
+	}
+	return rt
+}
@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sre/internal/metrics"
+)
+
+// Metrics counts backend calls and their latency per pattern, method and
+// status code on reg. Placed inside Retry it observes every attempt.
+func Metrics(reg *metrics.Registry) Middleware {
+	calls := reg.Counter("sre_http_client_calls_total", "Backend calls by endpoint pattern, method and status code.", "pattern", "method", "code")
+	seconds := reg.Counter("sre_http_client_call_seconds_total", "Time spent waiting for backend response headers.", "pattern", "method")
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(r *Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next(r)
+			seconds.With(r.Pattern, r.HTTP.Method).Add(time.Since(start).Seconds())
+			code := "error"
+			if err == nil {
+				code = strconv.Itoa(res.StatusCode)
+			}
+			calls.With(r.Pattern, r.HTTP.Method, code).Inc()
+			return res, err
+		}
+	}
+}
+
+// Logging logs every backend call at debug level and failed ones at warn level.
+func Logging() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(r *Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next(r)
+			attrs := []any{
+				"pattern", r.Pattern,
+				"method", r.HTTP.Method,
+				"attempt", r.Attempt,
+				"duration", time.Since(start),
+			}
+			ctx := r.HTTP.Context()
+			switch {
+			case err != nil:
+				slog.WarnContext(ctx, "backend call failed", append(attrs, "err", err)...)
+			case res.StatusCode >= http.StatusInternalServerError:
+				slog.WarnContext(ctx, "backend call failed", append(attrs, "status", res.StatusCode)...)
+			default:
+				slog.DebugContext(ctx, "backend call", append(attrs, "status", res.StatusCode)...)
+			}
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"sre/internal/flags"
+)
+
+// ErrCircuitOpen is returned while an endpoint's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// errBodyNotReplayable is returned when a request would be retried with a
+// body that was already sent and cannot be read again.
+var errBodyNotReplayable = errors.New("request body cannot be replayed")
+
+// Retry retries idempotent requests on transport errors and 5xx responses,
+// with exponential backoff. Its settings are read from store on every call.
+// POST and PATCH are never retried: the backend has no idempotency guarantees
+// for them. Neither are requests whose body cannot be replayed.
+func Retry(store *flags.Store) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(r *Request) (*http.Response, error) {
+			f := store.Get()
+			if !f.RetryEnabled || !idempotent(r.HTTP.Method) || !replayable(r.HTTP) {
+				return next(r)
+			}
+			ctx := r.HTTP.Context()
+			var res *http.Response
+			var err error
+			for i := 0; i < f.RetryMaxAttempts; i++ {
+				if i > 0 {
+					if werr := sleep(ctx, f.RetryBackoff.Std()<<(i-1)); werr != nil {
+						return nil, werr
+					}
+					if r, err = nextAttempt(r); err != nil {
+						return nil, err
+					}
+				}
+				res, err = next(r)
+				failed := err != nil || res.StatusCode >= http.StatusInternalServerError
+				if !failed || i == f.RetryMaxAttempts-1 || ctx.Err() != nil {
+					break
+				}
+				if res != nil {
+					_, _ = io.Copy(io.Discard, res.Body)
+					res.Body.Close()
+				}
+			}
+			return res, err
+		}
+	}
+}
+
+// nextAttempt clones r with a fresh body and an incremented Attempt.
+func nextAttempt(r *Request) (*Request, error) {
+	req := r.HTTP.Clone(r.HTTP.Context())
+	if !replayable(r.HTTP) {
+		return nil, errBodyNotReplayable
+	}
+	if r.HTTP.GetBody != nil {
+		body, err := r.HTTP.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	cp := *r
+	cp.HTTP = req
+	cp.Attempt++
+	return &cp, nil
+}
+
+// replayable reports whether req has no body or can get a fresh copy of it.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// CircuitBreaker keeps a breaker per endpoint pattern and fails fast with
+// ErrCircuitOpen while it is open. Its settings are read from store on every call.
+func CircuitBreaker(store *flags.Store) Middleware {
+	var mu sync.Mutex
+	breakers := make(map[string]*breaker)
+	get := func(pattern string) *breaker {
+		mu.Lock()
+		defer mu.Unlock()
+		b, ok := breakers[pattern]
+		if !ok {
+			b = &breaker{}
+			breakers[pattern] = b
+		}
+		return b
+	}
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(r *Request) (*http.Response, error) {
+			f := store.Get()
+			if !f.BreakerEnabled {
+				return next(r)
+			}
+			b := get(r.Pattern)
+			if !b.allow(time.Now()) {
+				return nil, ErrCircuitOpen
+			}
+			res, err := next(r)
+			failed := err != nil || res.StatusCode >= http.StatusInternalServerError
+			b.record(time.Now(), failed, f.BreakerThreshold, f.BreakerCooldown.Std())
+			return res, err
+		}
+	}
+}
+
+// breaker is a consecutive-failures circuit breaker. After the cooldown it
+// lets a single probe through (half-open); its outcome closes or reopens it.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) record(now time.Time, failed bool, threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= threshold {
+		b.openUntil = now.Add(cooldown)
+	}
+}
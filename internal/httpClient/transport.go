@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sre/internal/metrics"
+)
+
+// TransportConfig tunes the connection pool shared by every endpoint of a factory.
+type TransportConfig struct {
+	// DialTimeout bounds establishing a TCP connection.
+	DialTimeout time.Duration
+	// KeepAlive is the TCP keep-alive probe interval; negative disables probes.
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds waiting for response headers after the
+	// request is written. Zero means no limit beyond the request timeout.
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout is how long an idle pooled connection is kept.
+	IdleConnTimeout time.Duration
+	// MaxIdleConns caps idle connections across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle connections kept per host.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps connections per host, including in-use ones. Zero means no limit.
+	MaxConnsPerHost int
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+	// EnableHTTP2 negotiates HTTP/2 over TLS when the server supports it.
+	EnableHTTP2 bool
+}
+
+// DefaultTransportConfig returns a pool sized for a single busy backend host.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		DialTimeout:           1 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 0,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          256,
+		MaxIdleConnsPerHost:   128,
+		MaxConnsPerHost:       0,
+		DisableKeepAlives:     false,
+		EnableHTTP2:           true,
+	}
+}
+
+func (c TransportConfig) build(stats *poolStats) *http.Transport {
+	dialer := &net.Dialer{Timeout: c.DialTimeout, KeepAlive: c.KeepAlive}
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				stats.dialErrors.Add(1)
+				return nil, err
+			}
+			stats.dials.Add(1)
+			stats.open.Add(1)
+			return &trackedConn{Conn: conn, stats: stats}, nil
+		},
+		TLSHandshakeTimeout:   c.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+		IdleConnTimeout:       c.IdleConnTimeout,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       c.MaxConnsPerHost,
+		DisableKeepAlives:     c.DisableKeepAlives,
+		ForceAttemptHTTP2:     c.EnableHTTP2,
+	}
+	if !c.EnableHTTP2 {
+		// A non-nil empty map is the documented way to turn HTTP/2 off.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
+// PoolStats is a snapshot of the connection pool counters of a factory.
+type PoolStats struct {
+	// Dials is the number of connections established.
+	Dials int64
+	// DialErrors is the number of failed connection attempts.
+	DialErrors int64
+	// Open is the number of connections currently open, idle or in use.
+	Open int64
+	// InUse is the number of connections currently serving a request.
+	InUse int64
+	// Reused is the number of requests served by an already-open connection.
+	Reused int64
+	// Requests is the number of requests that obtained a connection.
+	Requests int64
+}
+
+type poolStats struct {
+	dials, dialErrors, open, inUse, reused, requests atomic.Int64
+}
+
+func (s *poolStats) snapshot() PoolStats {
+	return PoolStats{
+		Dials:      s.dials.Load(),
+		DialErrors: s.dialErrors.Load(),
+		Open:       s.open.Load(),
+		InUse:      s.inUse.Load(),
+		Reused:     s.reused.Load(),
+		Requests:   s.requests.Load(),
+	}
+}
+
+// trace counts connection reuse for a single request. The returned release
+// must be called once the response body is done with.
+func (s *poolStats) trace(ctx context.Context) (context.Context, func()) {
+	var got atomic.Bool
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			got.Store(true)
+			s.requests.Add(1)
+			s.inUse.Add(1)
+			if info.Reused {
+				s.reused.Add(1)
+			}
+		},
+	})
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			if got.Load() {
+				s.inUse.Add(-1)
+			}
+		})
+	}
+}
+
+type trackedConn struct {
+	net.Conn
+	stats *poolStats
+	once  sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() { c.stats.open.Add(-1) })
+	return c.Conn.Close()
+}
+
+// bodyReleaser calls release when the body hits EOF or is closed.
+type bodyReleaser struct {
+	body    io.ReadCloser
+	release func()
+}
+
+func (b *bodyReleaser) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if err != nil {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *bodyReleaser) Close() error {
+	b.release()
+	return b.body.Close()
+}
+
+// RegisterMetrics exposes the factory's connection pool counters on reg.
+func (f *DefaultEndpointFactory) RegisterMetrics(reg *metrics.Registry) {
+	load := func(v *atomic.Int64) func() float64 {
+		return func() float64 { return float64(v.Load()) }
+	}
+	reg.CounterFunc("sre_http_client_dials_total", "Backend connections established.", load(&f.stats.dials))
+	reg.CounterFunc("sre_http_client_dial_errors_total", "Failed backend connection attempts.", load(&f.stats.dialErrors))
+	reg.GaugeFunc("sre_http_client_conns_open", "Backend connections currently open.", load(&f.stats.open))
+	reg.GaugeFunc("sre_http_client_conns_in_use", "Backend connections currently serving a request.", load(&f.stats.inUse))
+	reg.CounterFunc("sre_http_client_conns_reused_total", "Backend requests served by a pooled connection.", load(&f.stats.reused))
+	reg.CounterFunc("sre_http_client_requests_total", "Backend requests that obtained a connection.", load(&f.stats.requests))
+}
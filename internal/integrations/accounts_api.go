@@ -2,8 +2,6 @@ package integrations
 
 import (
 	"context"
-	"encoding/json"
-	"io"
 	"net/http"
 
 	"sre/internal/domain"
@@ -35,19 +33,13 @@ type AccountsApi struct {
 }
 
 func (a *AccountsApi) GetLastByAccount(ctx context.Context, acc domain.Account) (*domain.TariffAdjustmentRequest, error) {
-	res, err := a.adjustmentsLastEndpoint.Get(ctx, httpclient.WithParam("id", acc.ID))
+	r, err := httpclient.GetJSON[LastAdjustmentResponse](ctx, a.adjustmentsLastEndpoint,
+		httpclient.WithParam("id", acc.ID),
+		httpclient.ExpectStatus(http.StatusOK),
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(res.Body)
-		return nil, &httpclient.HTTPError{StatusCode: res.StatusCode, Body: string(body)}
-	}
-	var r LastAdjustmentResponse
-	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		return nil, err
-	}
 	return &domain.TariffAdjustmentRequest{
 		TransactionID: r.TransactionID,
 		AccountID:     r.AccountID,
@@ -56,19 +48,13 @@ func (a *AccountsApi) GetLastByAccount(ctx context.Context, acc domain.Account)
 }
 
 func (a *AccountsApi) AllByAccount(ctx context.Context, acc domain.Account) ([]domain.TariffAdjustmentRequest, error) {
-	res, err := a.adjustmentsEndpoint.Get(ctx, httpclient.WithParam("id", acc.ID))
+	list, err := httpclient.GetJSON[[]AdjustmentResponse](ctx, a.adjustmentsEndpoint,
+		httpclient.WithParam("id", acc.ID),
+		httpclient.ExpectStatus(http.StatusOK),
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(res.Body)
-		return nil, &httpclient.HTTPError{StatusCode: res.StatusCode, Body: string(body)}
-	}
-	var list []AdjustmentResponse
-	if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
-		return nil, err
-	}
 	out, err := utils.Map(list, func(r AdjustmentResponse) domain.TariffAdjustmentRequest {
 		return domain.TariffAdjustmentRequest{
 			TransactionID: r.TransactionID,
@@ -84,51 +70,31 @@ func (a *AccountsApi) AllByAccount(ctx context.Context, acc domain.Account) ([]d
 
 func (a *AccountsApi) Create(ctx context.Context, input domain.TariffAdjustmentRequest, callbackURL string) error {
 	body := CreateAdjustmentBody{NewFee: input.NewFee, CallbackURL: callbackURL}
-	res, err := a.postAdjustmentEndpoint.Post(ctx,
+	_, err := httpclient.PostJSON[CreateAdjustmentBody, httpclient.Empty](ctx, a.postAdjustmentEndpoint, body,
 		httpclient.WithParam("id", input.AccountID),
-		httpclient.WithBody(body),
+		httpclient.WithHeader("Idempotency-Key", input.TransactionID),
+		httpclient.WithHeader("X-Requested-By", input.RequestedBy),
+		httpclient.ExpectStatus(http.StatusCreated, http.StatusOK),
 	)
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(res.Body)
-		return &httpclient.HTTPError{StatusCode: res.StatusCode, Body: string(b)}
-	}
-	return nil
+	return err
 }
 
 func (a *AccountsApi) UpdateFee(ctx context.Context, acc domain.Account, newFee float64) error {
-	res, err := a.accountEndpoint.Patch(ctx,
+	_, err := httpclient.PatchJSON[UpdateAccountBody, httpclient.Empty](ctx, a.accountEndpoint, UpdateAccountBody{MonthlyFee: newFee},
 		httpclient.WithParam("id", acc.ID),
-		httpclient.WithBody(UpdateAccountBody{MonthlyFee: newFee}),
+		httpclient.ExpectStatus(http.StatusNoContent, http.StatusOK),
 	)
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(res.Body)
-		return &httpclient.HTTPError{StatusCode: res.StatusCode, Body: string(b)}
-	}
-	return nil
+	return err
 }
 
 func (a *AccountsApi) Get(ctx context.Context, id domain.Account) (domain.Account, error) {
-	res, err := a.accountEndpoint.Get(ctx, httpclient.WithParam("id", id.ID))
+	r, err := httpclient.GetJSON[AccountResponse](ctx, a.accountEndpoint,
+		httpclient.WithParam("id", id.ID),
+		httpclient.ExpectStatus(http.StatusOK),
+	)
 	if err != nil {
 		return domain.Account{}, err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(res.Body)
-		return domain.Account{}, &httpclient.HTTPError{StatusCode: res.StatusCode, Body: string(b)}
-	}
-	var r AccountResponse
-	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		return domain.Account{}, err
-	}
 	return domain.Account{
 		ID:         r.ID,
 		Name:       r.Name,
@@ -2,8 +2,6 @@ package integrations
 
 import (
 	"context"
-	"fmt"
-	"io"
 	"net/http"
 
 	"sre/internal/domain"
@@ -30,16 +28,12 @@ func (p *AdjustmentFlowProcessor) BeginFlow(ctx context.Context, input domain.Ta
 		NewFee:      input.NewFee,
 		CallbackURL: callbackURL,
 	}
-	res, err := p.endpoint.Post(ctx, httpclient.WithBody(body))
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusAccepted && res.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("adjustment-approval-flow returned %d: %s", res.StatusCode, string(b))
-	}
-	return nil
+	_, err := httpclient.PostJSON[AdjustmentApprovalFlowBody, httpclient.Empty](ctx, p.endpoint, body,
+		httpclient.WithHeader("Idempotency-Key", input.TransactionID),
+		httpclient.WithHeader("X-Requested-By", input.RequestedBy),
+		httpclient.ExpectStatus(http.StatusAccepted, http.StatusOK),
+	)
+	return err
 }
 
 type AdjustmentApprovalFlowBody struct {
@@ -2,9 +2,6 @@ package integrations
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 
@@ -14,7 +11,13 @@ import (
 	"sre/internal/utils"
 )
 
-var _ usecases.AccountSearcher = (*SearchEngine)(nil)
+// maxCatalogBytes caps the full-catalog response of an empty-term search.
+const maxCatalogBytes = 64 << 20
+
+var (
+	_ usecases.AccountSearcher = (*SearchEngine)(nil)
+	_ usecases.AccountStreamer = (*SearchEngine)(nil)
+)
 
 // NewSearchEngine creates a client for the fintech search/accounts API.
 func NewSearchEngine(factory httpclient.EndpointFactory) *SearchEngine {
@@ -28,44 +31,50 @@ type SearchEngine struct {
 }
 
 func (s *SearchEngine) SearchByTerm(ctx context.Context, term string) ([]domain.Account, error) {
-	q := make(url.Values)
-	if term != "" {
-		q.Set("term", term)
-	}
-	var opts []httpclient.RequestOption
-	if len(q) > 0 {
-		opts = append(opts, httpclient.WithQuery(q))
-	}
-	res, err := s.getEndpoint.Get(ctx, opts...)
+	list, err := httpclient.GetJSON[[]SearchResultAccount](ctx, s.getEndpoint, searchOptions(term)...)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(res.Body)
-		return nil, &httpclient.HTTPError{StatusCode: res.StatusCode, Body: fmt.Sprintf("search API returned %d: %s", res.StatusCode, string(body))}
-	}
-	var list []SearchResultAccount
-	if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
-		return nil, err
-	}
-	out, err := utils.Map(list, func(r SearchResultAccount) domain.Account {
-		return domain.Account{
-			ID:         r.ID,
-			Name:       r.Name,
-			MonthlyFee: r.MonthlyFee,
-			Type:       r.Type,
-		}
-	})
+	out, err := utils.Map(list, SearchResultAccount.toDomain)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
+// StreamByTerm decodes the backend result one account at a time, so the
+// catalog is never materialized as a slice.
+func (s *SearchEngine) StreamByTerm(ctx context.Context, term string, fn func(domain.Account) error) error {
+	return httpclient.StreamJSON(ctx, s.getEndpoint, func(r SearchResultAccount) error {
+		return fn(r.toDomain())
+	}, searchOptions(term)...)
+}
+
+func searchOptions(term string) []httpclient.RequestOption {
+	var opts []httpclient.RequestOption
+	if term != "" {
+		q := make(url.Values)
+		q.Set("term", term)
+		opts = append(opts, httpclient.WithQuery(q))
+	}
+	return append(opts,
+		httpclient.ExpectStatus(http.StatusOK),
+		httpclient.WithMaxBodyBytes(maxCatalogBytes),
+	)
+}
+
 type SearchResultAccount struct {
 	ID         string  `json:"id"`
 	Name       string  `json:"name"`
 	MonthlyFee float64 `json:"monthly_fee"`
 	Type       string  `json:"type"`
 }
+
+func (r SearchResultAccount) toDomain() domain.Account {
+	return domain.Account{
+		ID:         r.ID,
+		Name:       r.Name,
+		MonthlyFee: r.MonthlyFee,
+		Type:       r.Type,
+	}
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds metrics and renders them in the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name()] {
+		panic("metrics: duplicate metric " + m.name())
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// Counter registers a monotonically increasing counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Vec {
+	v := newVec(name, help, "counter", labels)
+	r.register(v)
+	return v
+}
+
+// Gauge registers a gauge with the given label names.
+func (r *Registry) Gauge(name, help string, labels ...string) *Vec {
+	v := newVec(name, help, "gauge", labels)
+	r.register(v)
+	return v
+}
+
+// CounterFunc registers a counter whose value is read from fn at scrape time.
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{n: name, help: help, kind: "counter", fn: fn})
+}
+
+// GaugeFunc registers a gauge whose value is read from fn at scrape time.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{n: name, help: help, kind: "gauge", fn: fn})
+}
+
+// WriteText writes every metric in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	ms := make([]metric, len(r.metrics))
+	copy(ms, r.metrics)
+	r.mu.Unlock()
+	sort.Slice(ms, func(i, j int) bool { return ms[i].name() < ms[j].name() })
+	bw := bufio.NewWriter(w)
+	for _, m := range ms {
+		m.write(bw)
+	}
+	_ = bw.Flush()
+}
+
+// Handler serves the registry for scraping.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.WriteText(w)
+	})
+}
+
+// Vec is a counter or gauge partitioned by label values.
+type Vec struct {
+	n, help, kind string
+	labels        []string
+
+	mu     sync.RWMutex
+	series map[string]*Value
+}
+
+func newVec(name, help, kind string, labels []string) *Vec {
+	return &Vec{n: name, help: help, kind: kind, labels: labels, series: make(map[string]*Value)}
+}
+
+// With returns the series for the label values, in label-name order.
+func (v *Vec) With(values ...string) *Value {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.n, len(v.labels), len(values)))
+	}
+	key := labelString(v.labels, values)
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; !ok {
+		s = &Value{}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *Vec) name() string { return v.n }
+
+func (v *Vec) write(w io.Writer) {
+	writeHeader(w, v.n, v.help, v.kind)
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", v.n, k, formatFloat(v.series[k].Get()))
+	}
+	v.mu.RUnlock()
+}
+
+// Value is a single float series safe for concurrent use.
+type Value struct{ bits atomic.Uint64 }
+
+// Inc adds 1.
+func (s *Value) Inc() { s.Add(1) }
+
+// Add adds d, which may be negative for gauges.
+func (s *Value) Add(d float64) {
+	for {
+		old := s.bits.Load()
+		if s.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+d)) {
+			return
+		}
+	}
+}
+
+// Set replaces the value.
+func (s *Value) Set(x float64) { s.bits.Store(math.Float64bits(x)) }
+
+// Get returns the current value.
+func (s *Value) Get() float64 { return math.Float64frombits(s.bits.Load()) }
+
+type funcMetric struct {
+	n, help, kind string
+	fn            func() float64
+}
+
+func (m *funcMetric) name() string { return m.n }
+
+func (m *funcMetric) write(w io.Writer) {
+	writeHeader(w, m.n, m.help, m.kind)
+	fmt.Fprintf(w, "%s %s\n", m.n, formatFloat(m.fn()))
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func labelString(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(n)
+		b.WriteString("=")
+		b.WriteString(strconv.Quote(values[i]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
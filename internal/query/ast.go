@@ -0,0 +1,130 @@
+package query
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/utils"
+)
+
+// Field is an account attribute a query can filter, sort or project on.
+type Field string
+
+const (
+	FieldID   Field = "id"
+	FieldName Field = "name"
+	FieldType Field = "type"
+	FieldFee  Field = "monthly_fee"
+)
+
+// Expr is a parsed query expression.
+type Expr interface {
+	// Match reports whether the account satisfies the expression.
+	Match(a domain.Account) bool
+	String() string
+}
+
+// And matches when both sides match.
+type And struct{ Left, Right Expr }
+
+// Or matches when either side matches.
+type Or struct{ Left, Right Expr }
+
+// Not matches when Expr does not.
+type Not struct{ Expr Expr }
+
+// Text is a bare value; it matches account names.
+type Text struct{ Value string }
+
+// Compare tests one field against a value. Number is set for fee comparisons.
+type Compare struct {
+	Field  Field
+	Op     string
+	Value  string
+	Number float64
+}
+
+func (e And) Match(a domain.Account) bool { return e.Left.Match(a) && e.Right.Match(a) }
+func (e Or) Match(a domain.Account) bool  { return e.Left.Match(a) || e.Right.Match(a) }
+func (e Not) Match(a domain.Account) bool { return !e.Expr.Match(a) }
+func (e Text) Match(a domain.Account) bool {
+	return matchString(a.Name, e.Value)
+}
+
+func (e Compare) Match(a domain.Account) bool {
+	if e.Field == FieldFee {
+		switch e.Op {
+		case ":", "=":
+			return a.MonthlyFee == e.Number
+		case "!=":
+			return a.MonthlyFee != e.Number
+		case ">":
+			return a.MonthlyFee > e.Number
+		case ">=":
+			return a.MonthlyFee >= e.Number
+		case "<":
+			return a.MonthlyFee < e.Number
+		case "<=":
+			return a.MonthlyFee <= e.Number
+		}
+		return false
+	}
+	var ok bool
+	switch e.Field {
+	case FieldName:
+		ok = matchString(a.Name, e.Value)
+	case FieldType:
+		ok = matchExact(a.Type, e.Value)
+	case FieldID:
+		ok = matchExact(a.ID, e.Value)
+	}
+	if e.Op == "!=" {
+		return !ok
+	}
+	return ok
+}
+
+func (e And) String() string  { return fmt.Sprintf("(%s AND %s)", e.Left, e.Right) }
+func (e Or) String() string   { return fmt.Sprintf("(%s OR %s)", e.Left, e.Right) }
+func (e Not) String() string  { return fmt.Sprintf("NOT %s", e.Expr) }
+func (e Text) String() string { return fmt.Sprintf("%q", e.Value) }
+func (e Compare) String() string {
+	return fmt.Sprintf("%s%s%q", e.Field, e.Op, e.Value)
+}
+
+// matchString is the case- and accent-insensitive match used for names: a
+// pattern with * is a glob over the whole value or any of its words, anything
+// else is a substring match.
+func matchString(value, pattern string) bool {
+	v, p := utils.Fold(value), utils.Fold(pattern)
+	if !strings.Contains(p, "*") {
+		return strings.Contains(v, p)
+	}
+	if globMatch(p, v) {
+		return true
+	}
+	for _, w := range strings.Fields(v) {
+		if globMatch(p, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchExact compares case- and accent-insensitively, honoring * wildcards.
+func matchExact(value, pattern string) bool {
+	v, p := utils.Fold(value), utils.Fold(pattern)
+	if strings.Contains(p, "*") {
+		return globMatch(p, v)
+	}
+	return v == p
+}
+
+func globMatch(pattern, s string) bool {
+	// Only * is a wildcard in queries; escape path.Match's other metacharacters.
+	r := strings.NewReplacer(`\`, `\\`, "?", `\?`, "[", `\[`)
+	ok, err := path.Match(r.Replace(pattern), s)
+	return err == nil && ok
+}
@@ -0,0 +1,118 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 1-based column of the first character
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// ParseError reports where and why a query failed to parse.
+type ParseError struct {
+	// Pos is the 1-based column (in runes) where the problem was found.
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at column %d: %s", e.Pos, e.Msg)
+}
+
+func lex(input string) ([]token, error) {
+	rs := []rune(input)
+	var out []token
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		start := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			out = append(out, token{kind: tokLParen, text: "(", pos: start})
+			i++
+		case r == ')':
+			out = append(out, token{kind: tokRParen, text: ")", pos: start})
+			i++
+		case r == '"':
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(rs) {
+				if rs[i] == '\\' && i+1 < len(rs) {
+					b.WriteRune(rs[i+1])
+					i += 2
+					continue
+				}
+				if rs[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteRune(rs[i])
+				i++
+			}
+			if !closed {
+				return nil, &ParseError{Pos: start, Msg: "unterminated string"}
+			}
+			out = append(out, token{kind: tokString, text: b.String(), pos: start})
+		case strings.ContainsRune(":<>=!", r):
+			op := string(r)
+			if i+1 < len(rs) && rs[i+1] == '=' && r != ':' && r != '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &ParseError{Pos: start, Msg: `"!" must be followed by "="`}
+			}
+			out = append(out, token{kind: tokOp, text: op, pos: start})
+			i += len(op)
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune(`():<>=!"`, rs[j]) {
+				j++
+			}
+			word := string(rs[i:j])
+			kind := tokWord
+			switch strings.ToUpper(word) {
+			case "AND":
+				kind = tokAnd
+			case "OR":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			}
+			out = append(out, token{kind: kind, text: word, pos: start})
+			i = j
+		}
+	}
+	return append(out, token{kind: tokEOF, pos: len(rs) + 1}), nil
+}
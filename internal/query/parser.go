@@ -0,0 +1,163 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a search query into an expression tree.
+//
+// Grammar (keywords are case-insensitive; juxtaposition means AND):
+//
+//	expr    = or
+//	or      = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = "NOT" unary | primary
+//	primary = "(" expr ")" | field op value | value
+//	op      = ":" | "=" | "!=" | ">" | ">=" | "<" | "<="
+//
+// Fields are id, name, type and fee (alias monthly_fee). A bare value matches
+// the account name. String values may be quoted and may use * as a wildcard.
+func Parse(input string) (Expr, error) {
+	toks, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	if p.peek().kind == tokEOF {
+		return nil, &ParseError{Pos: 1, Msg: "empty query"}
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &ParseError{Pos: t.pos, Msg: "unexpected " + t.describe()}
+	}
+	return e, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokString, tokNot, tokLParen:
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: e}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return nil, &ParseError{Pos: c.pos, Msg: fmt.Sprintf("expected \")\" to close \"(\" at column %d, got %s", t.pos, c.describe())}
+		}
+		return e, nil
+	case tokString:
+		return Text{Value: t.text}, nil
+	case tokWord:
+		if p.peek().kind != tokOp {
+			return Text{Value: t.text}, nil
+		}
+		return p.parseComparison(t)
+	case tokEOF:
+		return nil, &ParseError{Pos: t.pos, Msg: "unexpected end of query, expected a term"}
+	default:
+		return nil, &ParseError{Pos: t.pos, Msg: "unexpected " + t.describe() + ", expected a term"}
+	}
+}
+
+func (p *parser) parseComparison(fieldTok token) (Expr, error) {
+	field, ok := fieldAliases[strings.ToLower(fieldTok.text)]
+	if !ok {
+		return nil, &ParseError{Pos: fieldTok.pos, Msg: fmt.Sprintf("unknown field %q (use id, name, type or fee)", fieldTok.text)}
+	}
+	opTok := p.next()
+	v := p.next()
+	if v.kind != tokWord && v.kind != tokString {
+		return nil, &ParseError{Pos: v.pos, Msg: fmt.Sprintf("expected a value after %s%s, got %s", fieldTok.text, opTok.text, v.describe())}
+	}
+	c := Compare{Field: field, Op: opTok.text, Value: v.text}
+	if field == FieldFee {
+		n, err := strconv.ParseFloat(v.text, 64)
+		if err != nil {
+			return nil, &ParseError{Pos: v.pos, Msg: fmt.Sprintf("fee must be a number, got %q", v.text)}
+		}
+		c.Number = n
+		return c, nil
+	}
+	switch opTok.text {
+	case ":", "=", "!=":
+		return c, nil
+	}
+	return nil, &ParseError{Pos: opTok.pos, Msg: fmt.Sprintf("operator %q only applies to fee", opTok.text)}
+}
+
+var fieldAliases = map[string]Field{
+	"id":          FieldID,
+	"name":        FieldName,
+	"type":        FieldType,
+	"fee":         FieldFee,
+	"monthly_fee": FieldFee,
+}
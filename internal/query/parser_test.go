@@ -0,0 +1,144 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"sre/internal/domain"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"maria", `"maria"`},
+		{`"maria silva"`, `"maria silva"`},
+		{"type:checking", `type:"checking"`},
+		{"fee>=10.5", `monthly_fee>="10.5"`},
+		{"monthly_fee<3", `monthly_fee<"3"`},
+		{"name!=jo*", `name!="jo*"`},
+		{"maria type:loan", `("maria" AND type:"loan")`},
+		{"a OR b c", `("a" OR ("b" AND "c"))`},
+		{"a and (b or c)", `("a" AND ("b" OR "c"))`},
+		{"NOT type:card", `NOT type:"card"`},
+		{"not not a", `NOT NOT "a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			e, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"", 1},
+		{"(a", 3},
+		{"a OR", 5},
+		{"color:red", 1},
+		{"fee>cheap", 5},
+		{"type>loan", 5},
+		{"name:", 6},
+		{")", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Parse(%q) error = %v, want a *ParseError", tt.input, err)
+			}
+			if pe.Pos != tt.pos {
+				t.Errorf("Parse(%q) error at column %d, want %d (%v)", tt.input, pe.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	acc := domain.Account{ID: "acc-7", Name: "José da Silva", Type: "checking", MonthlyFee: 12.5}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"jose", true},
+		{"SILVA", true},
+		{"maria", false},
+		{"name:jo*", true},
+		{"name:si*va", true},
+		{"name:ilva*", false},
+		{"type:checking", true},
+		{"type:check", false},
+		{"type:check*", true},
+		{"type!=loan", true},
+		{"id:acc-7", true},
+		{"fee=12.5", true},
+		{"fee>12.5", false},
+		{"fee>=12.5 fee<13", true},
+		{"type:loan OR fee<20", true},
+		{"NOT jose", false},
+		{"(maria OR jose) type:checking", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			e, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			if got := e.Match(acc); got != tt.want {
+				t.Errorf("%s matches %+v = %v, want %v", e, acc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []SortKey
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"-fee,name", []SortKey{{Field: FieldFee, Desc: true}, {Field: FieldName}}, false},
+		{" ID , -Type ", []SortKey{{Field: FieldID}, {Field: FieldType, Desc: true}}, false},
+		{"balance", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSort(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSort(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	accounts := []domain.Account{
+		{ID: "a", Name: "Bia", MonthlyFee: 10},
+		{ID: "b", Name: "ana", MonthlyFee: 30},
+		{ID: "c", Name: "Caio", MonthlyFee: 10},
+	}
+	Sort(accounts, []SortKey{{Field: FieldFee, Desc: true}, {Field: FieldName}})
+	var ids []string
+	for _, a := range accounts {
+		ids = append(ids, a.ID)
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("sorted IDs = %v, want %v", ids, want)
+	}
+}
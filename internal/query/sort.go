@@ -0,0 +1,101 @@
+package query
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"sre/internal/domain"
+)
+
+// SortKey orders results by one field.
+type SortKey struct {
+	Field Field
+	Desc  bool
+}
+
+// ParseSort parses a comma-separated list like "-monthly_fee,name"; a leading
+// "-" sorts that field in descending order.
+func ParseSort(s string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range splitList(s) {
+		desc := strings.HasPrefix(part, "-")
+		f, ok := fieldAliases[strings.ToLower(strings.TrimPrefix(part, "-"))]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", part)
+		}
+		keys = append(keys, SortKey{Field: f, Desc: desc})
+	}
+	return keys, nil
+}
+
+// ParseFields parses a comma-separated projection like "id,monthly_fee".
+func ParseFields(s string) ([]Field, error) {
+	var fields []Field
+	for _, part := range splitList(s) {
+		f, ok := fieldAliases[strings.ToLower(part)]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", part)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Sort orders accounts in place by keys; ties keep their relative order.
+func Sort(accounts []domain.Account, keys []SortKey) {
+	if len(keys) == 0 {
+		return
+	}
+	slices.SortStableFunc(accounts, func(a, b domain.Account) int {
+		for _, k := range keys {
+			var c int
+			switch k.Field {
+			case FieldID:
+				c = cmp.Compare(a.ID, b.ID)
+			case FieldName:
+				c = cmp.Compare(a.Name, b.Name)
+			case FieldType:
+				c = cmp.Compare(a.Type, b.Type)
+			case FieldFee:
+				c = cmp.Compare(a.MonthlyFee, b.MonthlyFee)
+			}
+			if k.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+// Project returns the selected fields of a, keyed by their JSON names.
+func Project(a domain.Account, fields []Field) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		switch f {
+		case FieldID:
+			out["id"] = a.ID
+		case FieldName:
+			out["name"] = a.Name
+		case FieldType:
+			out["type"] = a.Type
+		case FieldFee:
+			out["monthly_fee"] = a.MonthlyFee
+		}
+	}
+	return out
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.AdjustmentStateRepository = (*AdjustmentStateStore)(nil)
+
+// NewAdjustmentStateStore stores the state of each tariff adjustment as a
+// JSON file under dir, named by transaction ID.
+func NewAdjustmentStateStore(dir string) (*AdjustmentStateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &AdjustmentStateStore{dir: dir}, nil
+}
+
+type AdjustmentStateStore struct {
+	dir string
+}
+
+func (s *AdjustmentStateStore) Save(ctx context.Context, st domain.AdjustmentState) error {
+	path, err := s.path(st.TransactionID)
+	if err != nil {
+		return err
+	}
+	return writeJSONAtomic(path, st)
+}
+
+func (s *AdjustmentStateStore) Get(ctx context.Context, transactionID string) (domain.AdjustmentState, error) {
+	var st domain.AdjustmentState
+	path, err := s.path(transactionID)
+	if err != nil {
+		return st, err
+	}
+	if err := readJSON(path, &st); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return st, fmt.Errorf("tariff adjustment %q: %w", transactionID, domain.ErrNotFound)
+		}
+		return st, err
+	}
+	return st, nil
+}
+
+func (s *AdjustmentStateStore) path(transactionID string) (string, error) {
+	if transactionID == "" || strings.ContainsAny(transactionID, `/\.`) {
+		return "", fmt.Errorf("tariff adjustment %q: %w", transactionID, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, transactionID+".json"), nil
+}
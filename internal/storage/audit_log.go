@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.AuditLog = (*AuditLogFile)(nil)
+
+// maxAuditLine bounds the length of a record in the audit file.
+const maxAuditLine = 64 << 10
+
+// NewAuditLogFile opens the audit log at path, one JSON record per line,
+// creating it if needed. A trailing partial line, left by a crash during an
+// append, is cut off. The transactions of the requests recorded are indexed
+// in memory, so Requested does not read the file.
+func NewAuditLogFile(path string) (*AuditLogFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &AuditLogFile{path: path, f: f, requested: make(map[string]struct{})}
+	end, err := scanAudit(f, func(rec domain.AuditRecord) error {
+		l.index(rec)
+		return nil
+	})
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if size, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, err
+	} else if size != end {
+		slog.Warn("truncating partial audit record", "path", path, "bytes", size-end)
+		if err := f.Truncate(end); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+type AuditLogFile struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	last *domain.AuditRecord
+	// requested holds the transaction IDs of the adjustment requests
+	// recorded.
+	requested map[string]struct{}
+}
+
+// index makes rec the last record; the caller holds l.mu or owns l.
+func (l *AuditLogFile) index(rec domain.AuditRecord) {
+	l.last = &rec
+	if rec.Action == domain.AuditAdjustmentRequested && rec.TransactionID != "" {
+		l.requested[rec.TransactionID] = struct{}{}
+	}
+}
+
+func (l *AuditLogFile) Append(ctx context.Context, rec domain.AuditRecord) (domain.AuditRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rec.Seq, rec.PrevHash = 1, ""
+	if l.last != nil {
+		rec.Seq, rec.PrevHash = l.last.Seq+1, l.last.Hash
+	}
+	rec.Time = rec.Time.UTC()
+	rec.Hash = rec.ComputeHash()
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return rec, err
+	}
+	if _, err := l.f.Write(append(b, '\n')); err != nil {
+		return rec, fmt.Errorf("append audit record: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return rec, fmt.Errorf("sync audit log: %w", err)
+	}
+	l.index(rec)
+	return rec, nil
+}
+
+func (l *AuditLogFile) Requested(ctx context.Context, transactionID string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.requested[transactionID]
+	return ok, nil
+}
+
+// Query scans the whole file; the log is meant to be read rarely.
+func (l *AuditLogFile) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditRecord, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	out := []domain.AuditRecord{}
+	errLimit := errors.New("limit reached")
+	_, err = scanAudit(f, func(rec domain.AuditRecord) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !q.Matches(rec) {
+			return nil
+		}
+		out = append(out, rec)
+		if q.Limit > 0 && len(out) == q.Limit {
+			return errLimit
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errLimit) {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Close closes the file.
+func (l *AuditLogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// VerifyAuditFile checks the hash chain of the audit log at path and returns
+// how many records it holds. The error wraps domain.ErrAuditChainBroken when
+// a record was modified, removed or reordered.
+func VerifyAuditFile(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var prev *domain.AuditRecord
+	var n int64
+	end, err := scanAudit(f, func(rec domain.AuditRecord) error {
+		if err := rec.Follows(prev); err != nil {
+			return err
+		}
+		prev = &rec
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	if info, err := f.Stat(); err == nil && info.Size() != end {
+		return n, fmt.Errorf("%w: partial record after record %d", domain.ErrAuditChainBroken, n)
+	}
+	return n, nil
+}
+
+// scanAudit calls fn with each complete record of r from its start and
+// returns the offset just past the last one. A last line without its
+// newline is not complete; any other undecodable line is an error.
+func scanAudit(r io.ReadSeeker, fn func(domain.AuditRecord) error) (int64, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	br := bufio.NewReaderSize(r, maxAuditLine)
+	var end int64
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return end, nil
+		}
+		if err != nil {
+			return end, err
+		}
+		var rec domain.AuditRecord
+		if err := json.Unmarshal(bytes.TrimSpace(b), &rec); err != nil {
+			return end, fmt.Errorf("%w: line %d: %v", domain.ErrAuditChainBroken, line, err)
+		}
+		if err := fn(rec); err != nil {
+			return end, err
+		}
+		end += int64(len(b))
+	}
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sre/internal/domain"
+)
+
+// writeAuditLog appends n fee updates to a new audit log and returns its
+// path.
+func writeAuditLog(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := NewAuditLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < n; i++ {
+		fee := float64(10 + i)
+		rec := domain.AuditRecord{Action: domain.AuditFeeUpdated, AccountID: "acc-1", NewFee: &fee}
+		if _, err := l.Append(context.Background(), rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestVerifyAuditFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		want   int64
+		broken bool
+	}{
+		{
+			name:   "intact",
+			tamper: func(lines []string) []string { return lines },
+			want:   3,
+		},
+		{
+			name: "modified",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"new_fee":11`, `"new_fee":99`, 1)
+				return lines
+			},
+			want:   1,
+			broken: true,
+		},
+		{
+			name: "removed",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			want:   1,
+			broken: true,
+		},
+		{
+			name: "reordered",
+			tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			want:   1,
+			broken: true,
+		},
+		{
+			name: "first removed",
+			tamper: func(lines []string) []string {
+				return lines[1:]
+			},
+			want:   0,
+			broken: true,
+		},
+		{
+			name: "not JSON",
+			tamper: func(lines []string) []string {
+				lines[2] = "{oops"
+				return lines
+			},
+			want:   2,
+			broken: true,
+		},
+		{
+			name: "partial last record",
+			tamper: func(lines []string) []string {
+				lines[2] = lines[2][:len(lines[2])/2]
+				return lines
+			},
+			want:   2,
+			broken: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeAuditLog(t, 3)
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+			out := strings.Join(tt.tamper(lines), "\n")
+			if tt.name != "partial last record" {
+				out += "\n"
+			}
+			if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			n, err := VerifyAuditFile(path)
+			if n != tt.want {
+				t.Errorf("VerifyAuditFile counted %d records, want %d", n, tt.want)
+			}
+			if got := errors.Is(err, domain.ErrAuditChainBroken); got != tt.broken {
+				t.Errorf("VerifyAuditFile error = %v, want chain broken %v", err, tt.broken)
+			}
+		})
+	}
+}
+
+func TestAuditLogFileReopen(t *testing.T) {
+	path := writeAuditLog(t, 2)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"seq":3,"act`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	l, err := NewAuditLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ctx := context.Background()
+	rec, err := l.Append(ctx, domain.AuditRecord{Action: domain.AuditAdjustmentRequested, AccountID: "acc-1", TransactionID: "tx-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Seq != 3 {
+		t.Errorf("appended record has seq %d, want 3", rec.Seq)
+	}
+	if n, err := VerifyAuditFile(path); n != 3 || err != nil {
+		t.Errorf("VerifyAuditFile = %d, %v; want 3, nil", n, err)
+	}
+	recs, err := l.Query(ctx, domain.AuditQuery{AfterSeq: 1, Limit: 1})
+	if err != nil || len(recs) != 1 || recs[0].Seq != 2 {
+		t.Errorf("Query after seq 1 = %+v, %v; want record 2", recs, err)
+	}
+}
+
+func TestAuditLogFileRequested(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := NewAuditLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, rec := range []domain.AuditRecord{
+		{Action: domain.AuditAdjustmentRequested, AccountID: "acc-1", TransactionID: "tx-1"},
+		{Action: domain.AuditFeeUpdated, AccountID: "acc-1", TransactionID: "tx-2"},
+	} {
+		if _, err := l.Append(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	// The index is rebuilt from the file.
+	l, err = NewAuditLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for tx, want := range map[string]bool{"tx-1": true, "tx-2": false, "tx-3": false} {
+		if got, err := l.Requested(ctx, tx); got != want || err != nil {
+			t.Errorf("Requested(%q) = %v, %v; want %v", tx, got, err, want)
+		}
+	}
+}
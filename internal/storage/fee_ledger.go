@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.FeeLedger = (*FeeLedgerStore)(nil)
+
+// NewFeeLedgerStore stores the fee ledger of each account as a JSON file
+// under dir.
+func NewFeeLedgerStore(dir string) (*FeeLedgerStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FeeLedgerStore{dir: dir}, nil
+}
+
+type FeeLedgerStore struct {
+	dir string
+	// mu serializes read-modify-write cycles; fee changes are rare enough
+	// for one lock over all accounts.
+	mu sync.Mutex
+}
+
+func (s *FeeLedgerStore) Update(ctx context.Context, accountID, transactionID string, fn func(*domain.FeeHistoryEntry)) error {
+	path, err := s.path(accountID)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.read(path)
+	if err != nil {
+		return err
+	}
+	i := len(entries)
+	for j, e := range entries {
+		if e.TransactionID == transactionID {
+			i = j
+			break
+		}
+	}
+	if i == len(entries) {
+		entries = append(entries, domain.FeeHistoryEntry{
+			TransactionID: transactionID,
+			AccountID:     accountID,
+			Source:        domain.FeeSourceLedger,
+		})
+	}
+	fn(&entries[i])
+	return writeJSONAtomic(path, entries)
+}
+
+func (s *FeeLedgerStore) List(ctx context.Context, accountID string) ([]domain.FeeHistoryEntry, error) {
+	path, err := s.path(accountID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(path)
+}
+
+func (s *FeeLedgerStore) read(path string) ([]domain.FeeHistoryEntry, error) {
+	var entries []domain.FeeHistoryEntry
+	if err := readJSON(path, &entries); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *FeeLedgerStore) path(accountID string) (string, error) {
+	if accountID == "" || strings.ContainsAny(accountID, `/\`) || strings.HasPrefix(accountID, ".") {
+		return "", fmt.Errorf("fee ledger of account %q: %w", accountID, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, accountID+".json"), nil
+}
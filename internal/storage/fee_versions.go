@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.FeeVersionRepository = (*FeeVersionStore)(nil)
+
+// NewFeeVersionStore stores the fee version of each account as a JSON file
+// under dir, named by account ID.
+func NewFeeVersionStore(dir string) (*FeeVersionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FeeVersionStore{dir: dir}, nil
+}
+
+type FeeVersionStore struct {
+	dir string
+}
+
+func (s *FeeVersionStore) Save(ctx context.Context, v domain.FeeVersion) error {
+	path, err := s.path(v.AccountID)
+	if err != nil {
+		return err
+	}
+	return writeJSONAtomic(path, v)
+}
+
+func (s *FeeVersionStore) Get(ctx context.Context, accountID string) (domain.FeeVersion, error) {
+	var v domain.FeeVersion
+	path, err := s.path(accountID)
+	if err != nil {
+		return v, err
+	}
+	if err := readJSON(path, &v); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return v, fmt.Errorf("fee version of account %q: %w", accountID, domain.ErrNotFound)
+		}
+		return v, err
+	}
+	return v, nil
+}
+
+func (s *FeeVersionStore) path(accountID string) (string, error) {
+	if accountID == "" || strings.ContainsAny(accountID, `/\.`) {
+		return "", fmt.Errorf("fee version of account %q: %w", accountID, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, accountID+".json"), nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.NotificationRepository = (*NotificationStore)(nil)
+
+// NewNotificationStore stores the decision taken on each notification as a
+// JSON file under dir, named by transaction ID.
+func NewNotificationStore(dir string) (*NotificationStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &NotificationStore{dir: dir}, nil
+}
+
+type NotificationStore struct {
+	dir string
+}
+
+func (s *NotificationStore) Save(ctx context.Context, n domain.ProcessedNotification) error {
+	path, err := s.path(n.TransactionID)
+	if err != nil {
+		return err
+	}
+	return writeJSONAtomic(path, n)
+}
+
+func (s *NotificationStore) Get(ctx context.Context, transactionID string) (domain.ProcessedNotification, error) {
+	var n domain.ProcessedNotification
+	path, err := s.path(transactionID)
+	if err != nil {
+		return n, err
+	}
+	if err := readJSON(path, &n); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return n, fmt.Errorf("notification %q: %w", transactionID, domain.ErrNotFound)
+		}
+		return n, err
+	}
+	return n, nil
+}
+
+func (s *NotificationStore) path(transactionID string) (string, error) {
+	if transactionID == "" || strings.ContainsAny(transactionID, `/\.`) {
+		return "", fmt.Errorf("notification %q: %w", transactionID, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, transactionID+".json"), nil
+}
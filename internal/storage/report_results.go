@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.ReportResultStore = (*ReportResultStore)(nil)
+
+// NewReportResultStore stores report job results as files under dir. Job
+// state lives in memory, so results left by a previous run are removed.
+func NewReportResultStore(dir string) (*ReportResultStore, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ReportResultStore{dir: dir}, nil
+}
+
+type ReportResultStore struct {
+	dir string
+}
+
+func (s *ReportResultStore) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func (s *ReportResultStore) Open(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("report result %q: %w", name, domain.ErrNotFound)
+	}
+	return f, err
+}
+
+func (s *ReportResultStore) Delete(ctx context.Context, name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *ReportResultStore) path(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("report result %q: %w", name, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, name), nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.ReportSnapshotRepository = (*ReportSnapshotStore)(nil)
+
+// NewReportSnapshotStore stores one JSON file per snapshot under dir, plus
+// an .info file with its metadata so that listing does not read reports.
+func NewReportSnapshotStore(dir string) (*ReportSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ReportSnapshotStore{dir: dir}, nil
+}
+
+type ReportSnapshotStore struct {
+	dir string
+}
+
+func (s *ReportSnapshotStore) Save(ctx context.Context, snap domain.ReportSnapshot) error {
+	path, err := s.path(snap.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONAtomic(path, snap); err != nil {
+		return err
+	}
+	// The info is written last, so a listed snapshot always exists.
+	return writeJSONAtomic(infoPath(path), snap.ReportSnapshotInfo)
+}
+
+// List logs the snapshots that cannot be read and returns their IDs apart,
+// so that one corrupt file does not block listing and pruning the others.
+// Snapshots saved before .info files existed get theirs on the first
+// listing.
+func (s *ReportSnapshotStore) List(ctx context.Context) ([]domain.ReportSnapshotInfo, []string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := []domain.ReportSnapshotInfo{}
+	var unreadable []string
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || strings.HasPrefix(id, ".") {
+			continue
+		}
+		info, err := s.info(ctx, id)
+		if err != nil {
+			slog.WarnContext(ctx, "unreadable report snapshot", "id", id, "err", err)
+			unreadable = append(unreadable, id)
+			continue
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TakenAt.Before(out[j].TakenAt) })
+	return out, unreadable, nil
+}
+
+// info reads the metadata of snapshot id, from its .info file or else from
+// the snapshot itself.
+func (s *ReportSnapshotStore) info(ctx context.Context, id string) (domain.ReportSnapshotInfo, error) {
+	var info domain.ReportSnapshotInfo
+	path, err := s.path(id)
+	if err != nil {
+		return info, err
+	}
+	if err := readJSON(infoPath(path), &info); err == nil {
+		return info, nil
+	}
+	snap, err := s.Get(ctx, id)
+	if err != nil {
+		return info, err
+	}
+	if err := writeJSONAtomic(infoPath(path), snap.ReportSnapshotInfo); err != nil {
+		slog.WarnContext(ctx, "write report snapshot info failed", "id", id, "err", err)
+	}
+	return snap.ReportSnapshotInfo, nil
+}
+
+func (s *ReportSnapshotStore) Get(ctx context.Context, id string) (domain.ReportSnapshot, error) {
+	var snap domain.ReportSnapshot
+	path, err := s.path(id)
+	if err != nil {
+		return snap, err
+	}
+	if err := readJSON(path, &snap); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return snap, fmt.Errorf("report snapshot %q: %w", id, domain.ErrNotFound)
+		}
+		return snap, err
+	}
+	return snap, nil
+}
+
+func (s *ReportSnapshotStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{infoPath(path), path} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ReportSnapshotStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("report snapshot %q: %w", id, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func infoPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".info"
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.SagaRepository = (*SagaStore)(nil)
+
+// NewSagaStore stores one JSON file per saga under dir.
+func NewSagaStore(dir string) (*SagaStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &SagaStore{dir: dir}, nil
+}
+
+type SagaStore struct {
+	dir string
+}
+
+func (s *SagaStore) Save(ctx context.Context, a domain.Saga) error {
+	path, err := s.path(a.ID)
+	if err != nil {
+		return err
+	}
+	return writeJSONAtomic(path, a)
+}
+
+func (s *SagaStore) Get(ctx context.Context, id string) (domain.Saga, error) {
+	var a domain.Saga
+	path, err := s.path(id)
+	if err != nil {
+		return a, err
+	}
+	if err := readJSON(path, &a); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return a, fmt.Errorf("saga %q: %w", id, domain.ErrNotFound)
+		}
+		return a, err
+	}
+	return a, nil
+}
+
+func (s *SagaStore) List(ctx context.Context) ([]domain.Saga, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := []domain.Saga{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || strings.HasPrefix(id, ".") {
+			continue
+		}
+		a, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *SagaStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *SagaStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("saga %q: %w", id, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
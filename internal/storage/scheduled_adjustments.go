@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sre/internal/domain"
+	"sre/internal/usecases"
+)
+
+var _ usecases.ScheduledAdjustmentRepository = (*ScheduledAdjustmentStore)(nil)
+
+// NewScheduledAdjustmentStore stores one JSON file per scheduled adjustment
+// under dir.
+func NewScheduledAdjustmentStore(dir string) (*ScheduledAdjustmentStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ScheduledAdjustmentStore{dir: dir}, nil
+}
+
+type ScheduledAdjustmentStore struct {
+	dir string
+}
+
+func (s *ScheduledAdjustmentStore) Save(ctx context.Context, a domain.ScheduledAdjustment) error {
+	path, err := s.path(a.ID)
+	if err != nil {
+		return err
+	}
+	return writeJSONAtomic(path, a)
+}
+
+func (s *ScheduledAdjustmentStore) Get(ctx context.Context, id string) (domain.ScheduledAdjustment, error) {
+	var a domain.ScheduledAdjustment
+	path, err := s.path(id)
+	if err != nil {
+		return a, err
+	}
+	if err := readJSON(path, &a); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return a, fmt.Errorf("scheduled adjustment %q: %w", id, domain.ErrNotFound)
+		}
+		return a, err
+	}
+	return a, nil
+}
+
+func (s *ScheduledAdjustmentStore) List(ctx context.Context) ([]domain.ScheduledAdjustment, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := []domain.ScheduledAdjustment{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || strings.HasPrefix(id, ".") {
+			continue
+		}
+		a, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EffectiveAt.Before(out[j].EffectiveAt) })
+	return out, nil
+}
+
+func (s *ScheduledAdjustmentStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("scheduled adjustment %q: %w", id, domain.ErrNotFound)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
@@ -2,20 +2,40 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"sre/internal/auth"
 	"sre/internal/domain"
+	"sre/internal/flags"
 
 	"log/slog"
 )
 
 var _ AccountService = (*AccountServiceImpl)(nil)
 
+// Page sizes of fee history queries.
+const (
+	defaultFeeHistoryLimit = 50
+	maxFeeHistoryLimit     = 500
+)
+
 // AccountService exposes fintech account and tariff-adjustment operations.
 type AccountService interface {
-	UpdateFee(ctx context.Context, accountID string) error
+	UpdateFee(ctx context.Context, n domain.FeeNotification) error
 	SendTariffAdjustmentRequest(ctx context.Context, input domain.TariffAdjustmentRequest) error
 	GetTariffAdjustments(ctx context.Context, acc domain.Account) ([]domain.TariffAdjustmentRequest, error)
 	GetAccount(ctx context.Context, accountID string) (domain.Account, error)
+	GetFeeHistory(ctx context.Context, q domain.FeeHistoryQuery) (domain.FeeHistoryPage, error)
+	ScheduleTariffAdjustment(ctx context.Context, input domain.TariffAdjustmentRequest, effectiveAt time.Time) (domain.ScheduledAdjustment, error)
+	ListScheduledAdjustments(ctx context.Context, accountID string) ([]domain.ScheduledAdjustment, error)
+	CancelScheduledAdjustment(ctx context.Context, accountID, id string) (domain.ScheduledAdjustment, error)
+	PreviewTariffAdjustment(ctx context.Context, input domain.TariffAdjustmentRequest, params domain.ReportParams) (domain.AdjustmentPreview, error)
+	GetTariffAdjustment(ctx context.Context, transactionID string) (domain.AdjustmentState, error)
+	CancelTariffAdjustment(ctx context.Context, transactionID string) (domain.AdjustmentState, error)
+	RevertTariffAdjustment(ctx context.Context, transactionID string) (domain.AdjustmentState, error)
 }
 
 // NewAccountService creates an AccountService.
@@ -24,13 +44,52 @@ func NewAccountService(
 	adjustmentRepo TariffAdjustmentRepository,
 	flowProcessor AdjustmentFlowProcessor,
 	callbackBaseURL string,
+	opts ...AccountServiceOption,
 ) AccountService {
-	return &AccountServiceImpl{
+	s := &AccountServiceImpl{
 		accountRepo:   accountRepo,
 		adjustmentRepo: adjustmentRepo,
 		flowProcessor: flowProcessor,
 		callbackURL:   callbackBaseURL + "/accounts/notifications",
 	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// AccountServiceOption configures optional AccountServiceImpl dependencies.
+type AccountServiceOption func(*AccountServiceImpl)
+
+// WithRuntimeFlags makes the adjustment pipeline read its mode and timeout from store.
+func WithRuntimeFlags(store *flags.Store) AccountServiceOption {
+	return func(s *AccountServiceImpl) { s.flags = store }
+}
+
+// WithFeeListeners notifies listeners after each successful fee update.
+func WithFeeListeners(listeners ...FeeUpdateListener) AccountServiceOption {
+	return func(s *AccountServiceImpl) { s.feeListeners = append(s.feeListeners, listeners...) }
+}
+
+// WithFeeLedger tracks each adjustment from request to applied fee in
+// ledger, for GetFeeHistory.
+func WithFeeLedger(ledger FeeLedger) AccountServiceOption {
+	return func(s *AccountServiceImpl) { s.ledger = ledger }
+}
+
+// WithScheduler holds adjustments with a future effective time in sched,
+// which sends them through this service when they are due.
+func WithScheduler(sched *AdjustmentScheduler) AccountServiceOption {
+	return func(s *AccountServiceImpl) {
+		s.scheduler = sched
+		sched.send = s.sendScheduled
+	}
+}
+
+// WithAuditLog records every adjustment request, approval notification and
+// fee update in log. A record that cannot be written fails the operation.
+func WithAuditLog(log AuditLog) AccountServiceOption {
+	return func(s *AccountServiceImpl) { s.audit = log }
 }
 
 type AccountServiceImpl struct {
@@ -38,36 +97,284 @@ type AccountServiceImpl struct {
 	adjustmentRepo TariffAdjustmentRepository
 	flowProcessor  AdjustmentFlowProcessor
 	callbackURL    string
+	flags          *flags.Store
+	feeListeners   []FeeUpdateListener
+	audit          AuditLog
+	ledger         FeeLedger
+	scheduler      *AdjustmentScheduler
+	bulk           *BulkAdjuster
+	states         AdjustmentStateRepository
+	sagas          *SagaOrchestrator
+	notifications  NotificationRepository
+	versions       FeeVersionRepository
+	approvalStatus string
+	// stateMu serializes adjustment state changes, so a cancellation cannot
+	// race an approval.
+	stateMu sync.Mutex
+	// notifyLocks serializes the notifications of each account, so that
+	// they are checked against its fee version one at a time.
+	notifyLocks accountLocks
 }
 
 func (s *AccountServiceImpl) SendTariffAdjustmentRequest(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	input.RequestedBy = requestedBy(ctx)
 	slog.InfoContext(ctx, "sending tariff adjustment request", "input", input)
+	if err := s.recordRequest(ctx, input); err != nil {
+		return err
+	}
+	if err := s.dispatch(ctx, input); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrBackendCall, err)
+	}
+	return nil
+}
+
+// dispatch sends a recorded adjustment to the backend in the pipeline mode
+// of the runtime flags.
+func (s *AccountServiceImpl) dispatch(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	f := s.flags.Get()
+	if s.sagas != nil && f.AdjustmentAsync {
+		go func() {
+			if err := s.sagas.execute(context.WithoutCancel(ctx), input); err != nil {
+				slog.ErrorContext(ctx, "tariff adjustment saga failed", "transaction_id", input.TransactionID, "err", err)
+			}
+		}()
+		return nil
+	}
+	if !f.AdjustmentAsync {
+		// Synchronous mode: the caller gets the outcome of both backend calls.
+		return s.startPipeline(ctx, input, f.AdjustmentTimeout.Std())
+	}
 	go func() {
-		if err := s.adjustmentRepo.Create(context.Background(), input, s.callbackURL); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), f.AdjustmentTimeout.Std())
+		defer cancel()
+		if err := s.adjustmentRepo.Create(ctx, input, s.callbackURL); err != nil {
 			slog.ErrorContext(ctx, "create tariff adjustment failed", "err", err)
 		}
 	}()
 	go func() {
-		if err := s.flowProcessor.BeginFlow(context.Background(), input, s.callbackURL); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), f.AdjustmentTimeout.Std())
+		defer cancel()
+		if err := s.flowProcessor.BeginFlow(ctx, input, s.callbackURL); err != nil {
 			slog.ErrorContext(ctx, "begin adjustment flow failed", "err", err)
 		}
 	}()
 	return nil
 }
 
-func (s *AccountServiceImpl) UpdateFee(ctx context.Context, accountID string) error {
-	acc := domain.Account{ID: accountID}
-	last, err := s.adjustmentRepo.GetLastByAccount(ctx, acc)
+// recordRequest writes a request to the audit log and the ledger before it
+// is sent. It is idempotent per transaction, so every attempt to send an
+// adjustment calls it: a request recorded already is left as it is.
+func (s *AccountServiceImpl) recordRequest(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	if done, err := s.isRecorded(ctx, input); err != nil || done {
+		return err
+	}
+	oldFee := s.currentFee(ctx, input.AccountID)
+	if err := s.record(ctx, domain.AuditRecord{
+		Action:        domain.AuditAdjustmentRequested,
+		AccountID:     input.AccountID,
+		TransactionID: input.TransactionID,
+		OldFee:        oldFee,
+		NewFee:        &input.NewFee,
+		RevertOf:      input.RevertOf,
+	}); err != nil {
+		return err
+	}
+	if err := s.saveRequested(ctx, input, oldFee); err != nil {
+		return err
+	}
+	s.track(ctx, input.AccountID, input.TransactionID, func(e *domain.FeeHistoryEntry) {
+		now := time.Now().UTC()
+		e.OldFee = oldFee
+		e.NewFee = input.NewFee
+		e.Status = domain.FeeRequested
+		e.RequestedBy = input.RequestedBy
+		e.RevertOf = input.RevertOf
+		if e.RequestedAt == nil {
+			e.RequestedAt = &now
+		}
+	})
+	return nil
+}
+
+// isRecorded reports whether the request of input was recorded: it has an
+// adjustment state, which is saved after its audit record, or without states
+// an audit record.
+func (s *AccountServiceImpl) isRecorded(ctx context.Context, input domain.TariffAdjustmentRequest) (bool, error) {
+	if s.states != nil {
+		_, err := s.states.Get(ctx, input.TransactionID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if s.audit == nil {
+		return false, nil
+	}
+	return s.audit.Requested(ctx, input.TransactionID)
+}
+
+// startPipeline records the adjustment and starts its approval flow in
+// parallel, both bounded by timeout, and returns once both backend calls are
+// done. With sagas, it runs the adjustment's saga instead, whose steps bound
+// each call with the adjustment timeout of the runtime flags. Neither stops
+// with ctx: a caller that goes away must not leave an adjustment recorded
+// without its flow, or void one the backend may have recorded, so ctx only
+// bounds the wait.
+func (s *AccountServiceImpl) startPipeline(ctx context.Context, input domain.TariffAdjustmentRequest, timeout time.Duration) error {
+	done := make(chan error, 1)
+	if s.sagas != nil {
+		go func() { done <- s.sagas.execute(context.WithoutCancel(ctx), input) }()
+	} else {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+			defer cancel()
+			createErr := make(chan error, 1)
+			go func() { createErr <- s.adjustmentRepo.Create(ctx, input, s.callbackURL) }()
+			flowErr := s.flowProcessor.BeginFlow(ctx, input, s.callbackURL)
+			done <- errors.Join(<-createErr, flowErr)
+		}()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		slog.WarnContext(ctx, "stopped waiting for tariff adjustment", "transaction_id", input.TransactionID, "err", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// requestedBy names the principal behind ctx: its authenticated client, or
+// "system" for work not started by a request.
+func requestedBy(ctx context.Context) string {
+	if c, ok := auth.ClientFrom(ctx); ok {
+		return c.Name
+	}
+	return "system"
+}
+
+// UpdateFee processes an adjustment notification; only an approval changes
+// the fee. With notification dedupe, notifications of one account are
+// processed one at a time and each transaction is approved only once.
+func (s *AccountServiceImpl) UpdateFee(ctx context.Context, n domain.FeeNotification) error {
+	accountID := n.AccountID
+	if s.notifications != nil {
+		lock := s.notifyLocks.of(accountID)
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	if dup, err := s.isDuplicate(ctx, n); err != nil || dup {
+		return err
+	}
+	if err := s.record(ctx, domain.AuditRecord{
+		Action:        domain.AuditAdjustmentNotified,
+		AccountID:     accountID,
+		TransactionID: n.TransactionID,
+		Status:        n.Status,
+	}); err != nil {
+		return err
+	}
+	if !s.isApproval(n.Status) {
+		s.decide(ctx, n, domain.NotificationIgnored, fmt.Sprintf("status %q is not an approval", n.Status))
+		return nil
+	}
+	if ignore, err := s.noteApproval(ctx, n); err != nil || ignore {
+		if ignore {
+			s.decide(ctx, n, domain.NotificationIgnored, "adjustment was cancelled")
+		}
+		return err
+	}
+	last, decision, reason, err := s.adjustmentToApply(ctx, n)
 	if err != nil {
 		return err
 	}
-	slog.InfoContext(ctx, "applying last tariff adjustment", "adjustment", last)
+	s.trackApproval(ctx, n)
+	if decision != domain.NotificationApplied {
+		s.decide(ctx, n, decision, reason)
+		return nil
+	}
+	slog.InfoContext(ctx, "applying tariff adjustment", "adjustment", last)
+	acc := domain.Account{ID: accountID}
+	oldFee := s.currentFee(ctx, accountID)
+	// The fee update is audited before it is written, so that no fee changes
+	// without a record; a write that fails then gets a record of its own.
+	rec := domain.AuditRecord{
+		Action:        domain.AuditFeeUpdated,
+		AccountID:     accountID,
+		TransactionID: last.TransactionID,
+		OldFee:        oldFee,
+		NewFee:        &last.NewFee,
+	}
+	if err := s.record(ctx, rec); err != nil {
+		return err
+	}
 	if err := s.accountRepo.UpdateFee(ctx, acc, last.NewFee); err != nil {
+		rec.Action, rec.Status = domain.AuditFeeUpdateFailed, err.Error()
+		_ = s.record(ctx, rec)
+		return err
+	}
+	s.noteApplied(ctx, last.TransactionID, oldFee)
+	s.track(ctx, accountID, last.TransactionID, func(e *domain.FeeHistoryEntry) {
+		now := time.Now().UTC()
+		if e.OldFee == nil {
+			e.OldFee = oldFee
+		}
+		e.NewFee = last.NewFee
+		e.Status = domain.FeeApplied
+		e.AppliedAt = &now
+	})
+	for _, l := range s.feeListeners {
+		l.FeeUpdated(accountID, last.NewFee)
+	}
+	if err := s.settle(ctx, last); err != nil {
 		return err
 	}
+	s.decide(ctx, n, domain.NotificationApplied, reason)
+	return nil
+}
+
+// record appends rec to the audit log, if there is one, on behalf of the
+// principal behind ctx.
+func (s *AccountServiceImpl) record(ctx context.Context, rec domain.AuditRecord) error {
+	if s.audit == nil {
+		return nil
+	}
+	rec.Time = time.Now()
+	rec.Principal = requestedBy(ctx)
+	if _, err := s.audit.Append(ctx, rec); err != nil {
+		slog.ErrorContext(ctx, "audit record failed", "action", rec.Action, "account_id", rec.AccountID, "err", err)
+		return fmt.Errorf("audit %s: %w", rec.Action, err)
+	}
 	return nil
 }
 
+// track updates the ledger entry of a transaction, if there is a ledger.
+// The ledger is a convenience view, so failures are only logged.
+func (s *AccountServiceImpl) track(ctx context.Context, accountID, transactionID string, fn func(*domain.FeeHistoryEntry)) {
+	if s.ledger == nil {
+		return
+	}
+	if err := s.ledger.Update(ctx, accountID, transactionID, fn); err != nil {
+		slog.ErrorContext(ctx, "fee ledger update failed", "account_id", accountID, "transaction_id", transactionID, "err", err)
+	}
+}
+
+// currentFee reads the account's fee for the audit log and the ledger; it
+// is nil without either or when the account cannot be read.
+func (s *AccountServiceImpl) currentFee(ctx context.Context, accountID string) *float64 {
+	if s.audit == nil && s.ledger == nil {
+		return nil
+	}
+	acc, err := s.accountRepo.Get(ctx, domain.Account{ID: accountID})
+	if err != nil {
+		slog.WarnContext(ctx, "read current fee failed", "account_id", accountID, "err", err)
+		return nil
+	}
+	return &acc.MonthlyFee
+}
+
 func (s *AccountServiceImpl) GetTariffAdjustments(ctx context.Context, acc domain.Account) ([]domain.TariffAdjustmentRequest, error) {
 	return s.adjustmentRepo.AllByAccount(ctx, acc)
 }
@@ -75,3 +382,81 @@ func (s *AccountServiceImpl) GetTariffAdjustments(ctx context.Context, acc domai
 func (s *AccountServiceImpl) GetAccount(ctx context.Context, accountID string) (domain.Account, error) {
 	return s.accountRepo.Get(ctx, domain.Account{ID: accountID})
 }
+
+// GetFeeHistory merges the backend's adjustment history of an account with
+// the local ledger. Backend entries keep the backend's order, which is
+// chronological; adjustments the backend does not list (yet) follow. Entries
+// the ledger does not know get their old fee from the entry before them.
+func (s *AccountServiceImpl) GetFeeHistory(ctx context.Context, q domain.FeeHistoryQuery) (domain.FeeHistoryPage, error) {
+	if q.Limit == 0 {
+		q.Limit = defaultFeeHistoryLimit
+	}
+	if q.Limit < 0 || q.Limit > maxFeeHistoryLimit || q.Offset < 0 {
+		return domain.FeeHistoryPage{}, fmt.Errorf("%w: limit must be between 1 and %d and offset not negative", domain.ErrInvalidFeeHistoryQuery, maxFeeHistoryLimit)
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return domain.FeeHistoryPage{}, fmt.Errorf("%w: from must be before to", domain.ErrInvalidFeeHistoryQuery)
+	}
+	var page domain.FeeHistoryPage
+	var ledger []domain.FeeHistoryEntry
+	if s.ledger != nil {
+		var err error
+		if ledger, err = s.ledger.List(ctx, q.AccountID); err != nil {
+			return page, err
+		}
+	}
+	backend, err := s.adjustmentRepo.AllByAccount(ctx, domain.Account{ID: q.AccountID})
+	if err != nil {
+		if s.ledger == nil {
+			return page, err
+		}
+		slog.WarnContext(ctx, "backend adjustment history unavailable, serving the ledger only", "account_id", q.AccountID, "err", err)
+		page.Partial = true
+	}
+
+	byTx := make(map[string]int, len(ledger))
+	for i, e := range ledger {
+		byTx[e.TransactionID] = i
+	}
+	used := make([]bool, len(ledger))
+	timeline := make([]domain.FeeHistoryEntry, 0, len(backend)+len(ledger))
+	var prevFee *float64
+	for _, a := range backend {
+		e := domain.FeeHistoryEntry{
+			TransactionID: a.TransactionID,
+			AccountID:     q.AccountID,
+			NewFee:        a.NewFee,
+			Status:        domain.FeeRecorded,
+			Source:        domain.FeeSourceBackend,
+		}
+		if i, ok := byTx[a.TransactionID]; ok && !used[i] {
+			e, used[i] = ledger[i], true
+		}
+		if e.OldFee == nil {
+			e.OldFee = prevFee
+		}
+		fee := e.NewFee
+		prevFee = &fee
+		timeline = append(timeline, e)
+	}
+	for i, e := range ledger {
+		if !used[i] {
+			timeline = append(timeline, e)
+		}
+	}
+
+	page.Entries = []domain.FeeHistoryEntry{}
+	for _, e := range timeline {
+		if !q.Matches(e) {
+			continue
+		}
+		if page.Total >= q.Offset && len(page.Entries) < q.Limit {
+			page.Entries = append(page.Entries, e)
+		}
+		page.Total++
+	}
+	if next := q.Offset + len(page.Entries); next < page.Total {
+		page.NextOffset = next
+	}
+	return page, nil
+}
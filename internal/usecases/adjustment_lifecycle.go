@@ -0,0 +1,277 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sre/internal/domain"
+)
+
+// WithAdjustmentStates tracks where each adjustment stands in repo, so it
+// can be cancelled before its approval and reverted once applied.
+func WithAdjustmentStates(repo AdjustmentStateRepository) AccountServiceOption {
+	return func(s *AccountServiceImpl) { s.states = repo }
+}
+
+// saveRequested stores the state of an adjustment about to be sent.
+func (s *AccountServiceImpl) saveRequested(ctx context.Context, input domain.TariffAdjustmentRequest, oldFee *float64) error {
+	if s.states == nil {
+		return nil
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	now := time.Now().UTC()
+	st, err := s.states.Get(ctx, input.TransactionID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		st = domain.AdjustmentState{TransactionID: input.TransactionID, RequestedAt: now}
+	}
+	st.AccountID = input.AccountID
+	st.OldFee = oldFee
+	st.NewFee = input.NewFee
+	st.Status = domain.FeeRequested
+	st.RequestedBy = input.RequestedBy
+	st.RevertOf = input.RevertOf
+	st.UpdatedAt = now
+	return s.states.Save(ctx, st)
+}
+
+// noteApproval moves a requested adjustment to approved. It reports whether
+// the notification must be ignored because the adjustment was cancelled.
+// Adjustments this API did not send are left to UpdateFee as before.
+func (s *AccountServiceImpl) noteApproval(ctx context.Context, n domain.FeeNotification) (bool, error) {
+	if s.states == nil || n.TransactionID == "" {
+		return false, nil
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, n.TransactionID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	case st.Status == domain.FeeCancelled:
+		return true, nil
+	case st.Status != domain.FeeRequested:
+		return false, nil
+	}
+	st.Status, st.UpdatedAt = domain.FeeApproved, time.Now().UTC()
+	return false, s.states.Save(ctx, st)
+}
+
+// isCancelled reports whether transactionID is a cancelled adjustment.
+func (s *AccountServiceImpl) isCancelled(ctx context.Context, transactionID string) bool {
+	if s.states == nil {
+		return false
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, transactionID)
+	return err == nil && st.Status == domain.FeeCancelled
+}
+
+// noteApplied marks an adjustment applied, keeping the fee it replaced for a
+// later revert. The fee is already written, so failures are only logged.
+func (s *AccountServiceImpl) noteApplied(ctx context.Context, transactionID string, oldFee *float64) {
+	if s.states == nil {
+		return
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, transactionID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return
+	}
+	if err == nil {
+		if oldFee != nil {
+			st.OldFee = oldFee
+		}
+		st.Status, st.UpdatedAt = domain.FeeApplied, time.Now().UTC()
+		err = s.states.Save(ctx, st)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "save adjustment state failed", "transaction_id", transactionID, "err", err)
+	}
+}
+
+// GetTariffAdjustment returns the state of an adjustment sent through this
+// API.
+func (s *AccountServiceImpl) GetTariffAdjustment(ctx context.Context, transactionID string) (domain.AdjustmentState, error) {
+	if s.states == nil {
+		return domain.AdjustmentState{}, fmt.Errorf("tariff adjustment %q: %w", transactionID, domain.ErrNotFound)
+	}
+	return s.states.Get(ctx, transactionID)
+}
+
+// CancelTariffAdjustment cancels an adjustment whose approval was not
+// notified yet, or a pending scheduled one. The backend is not told; its
+// notification for the adjustment is ignored instead.
+func (s *AccountServiceImpl) CancelTariffAdjustment(ctx context.Context, transactionID string) (domain.AdjustmentState, error) {
+	if s.states != nil {
+		st, err := s.cancelRequested(ctx, transactionID)
+		if !errors.Is(err, domain.ErrNotFound) {
+			return st, err
+		}
+	}
+	if s.scheduler != nil {
+		if a, err := s.scheduler.repo.Get(ctx, transactionID); err == nil {
+			a, err = s.CancelScheduledAdjustment(ctx, a.AccountID, transactionID)
+			if errors.Is(err, domain.ErrScheduleNotPending) {
+				err = fmt.Errorf("%w: %v", domain.ErrNotCancellable, err)
+			}
+			st := domain.AdjustmentState{
+				TransactionID: a.ID,
+				AccountID:     a.AccountID,
+				NewFee:        a.NewFee,
+				Status:        domain.FeeCancelled,
+				RequestedBy:   a.RequestedBy,
+				RequestedAt:   a.CreatedAt,
+				UpdatedAt:     time.Now().UTC(),
+			}
+			return st, err
+		}
+	}
+	return domain.AdjustmentState{}, fmt.Errorf("tariff adjustment %q: %w", transactionID, domain.ErrNotFound)
+}
+
+func (s *AccountServiceImpl) cancelRequested(ctx context.Context, transactionID string) (domain.AdjustmentState, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, transactionID)
+	if err != nil {
+		return st, err
+	}
+	if st.Status != domain.FeeRequested {
+		return st, fmt.Errorf("%w: it is %s", domain.ErrNotCancellable, st.Status)
+	}
+	return st, s.markCancelled(ctx, &st, false)
+}
+
+// markCancelled audits and saves the cancellation of st; the caller holds
+// s.stateMu.
+func (s *AccountServiceImpl) markCancelled(ctx context.Context, st *domain.AdjustmentState, voided bool) error {
+	rec := domain.AuditRecord{
+		Action:        domain.AuditAdjustmentCancelled,
+		AccountID:     st.AccountID,
+		TransactionID: st.TransactionID,
+		NewFee:        &st.NewFee,
+	}
+	if voided {
+		rec.Status = "voided"
+	}
+	if err := s.record(ctx, rec); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	st.Status, st.Voided, st.UpdatedAt = domain.FeeCancelled, voided, now
+	if err := s.states.Save(ctx, *st); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "tariff adjustment cancelled", "transaction_id", st.TransactionID, "account_id", st.AccountID, "voided", voided)
+	s.track(ctx, st.AccountID, st.TransactionID, func(e *domain.FeeHistoryEntry) {
+		e.Status = domain.FeeCancelled
+		e.CancelledAt = &now
+	})
+	return nil
+}
+
+// RevertTariffAdjustment sends a compensating adjustment that restores the
+// fee an applied adjustment replaced, and returns its state. Each adjustment
+// is reverted at most once.
+func (s *AccountServiceImpl) RevertTariffAdjustment(ctx context.Context, transactionID string) (domain.AdjustmentState, error) {
+	if s.states == nil {
+		return domain.AdjustmentState{}, fmt.Errorf("tariff adjustment %q: %w", transactionID, domain.ErrNotFound)
+	}
+	input, err := s.claimRevert(ctx, transactionID)
+	if err != nil {
+		return domain.AdjustmentState{}, err
+	}
+	slog.InfoContext(ctx, "reverting tariff adjustment", "transaction_id", transactionID, "revert_transaction_id", input.TransactionID)
+	if err := s.SendTariffAdjustmentRequest(ctx, input); err != nil {
+		// Let the revert be tried again; the failed one never got approved
+		// or restores the same fee.
+		s.unlinkRevert(ctx, transactionID)
+		return domain.AdjustmentState{}, err
+	}
+	s.track(ctx, input.AccountID, transactionID, func(e *domain.FeeHistoryEntry) { e.RevertedBy = input.TransactionID })
+	return s.states.Get(ctx, input.TransactionID)
+}
+
+// claimRevert checks that transactionID can be reverted and links it to the
+// compensating adjustment it returns. Only the adjustment the account's fee
+// comes from can be: reverting an older one would undo the later ones.
+func (s *AccountServiceImpl) claimRevert(ctx context.Context, transactionID string) (domain.TariffAdjustmentRequest, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, transactionID)
+	if err != nil {
+		return domain.TariffAdjustmentRequest{}, err
+	}
+	switch {
+	case st.Status != domain.FeeApplied:
+		err = fmt.Errorf("%w: it is %s", domain.ErrNotRevertible, st.Status)
+	case st.RevertedBy != "":
+		err = fmt.Errorf("%w: already reverted by %s", domain.ErrNotRevertible, st.RevertedBy)
+	case st.OldFee == nil:
+		err = fmt.Errorf("%w: the fee it replaced is unknown", domain.ErrNotRevertible)
+	}
+	if err != nil {
+		return domain.TariffAdjustmentRequest{}, err
+	}
+	current, err := s.isCurrentFee(ctx, st)
+	if err != nil {
+		return domain.TariffAdjustmentRequest{}, err
+	}
+	if !current {
+		return domain.TariffAdjustmentRequest{}, fmt.Errorf("%w: a later adjustment changed the fee", domain.ErrNotRevertible)
+	}
+	input := domain.TariffAdjustmentRequest{
+		TransactionID: uuid.NewString(),
+		AccountID:     st.AccountID,
+		NewFee:        *st.OldFee,
+		RevertOf:      transactionID,
+	}
+	st.RevertedBy, st.UpdatedAt = input.TransactionID, time.Now().UTC()
+	return input, s.states.Save(ctx, st)
+}
+
+// isCurrentFee reports whether the account's fee comes from st: it is the
+// account's fee version, or without one the fee equals st.NewFee.
+func (s *AccountServiceImpl) isCurrentFee(ctx context.Context, st domain.AdjustmentState) (bool, error) {
+	if s.versions != nil {
+		v, err := s.versions.Get(ctx, st.AccountID)
+		if err == nil {
+			return v.TransactionID == st.TransactionID, nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return false, err
+		}
+	}
+	acc, err := s.accountRepo.Get(ctx, domain.Account{ID: st.AccountID})
+	if err != nil {
+		return false, err
+	}
+	return acc.MonthlyFee == st.NewFee, nil
+}
+
+// unlinkRevert clears the compensating adjustment of transactionID.
+func (s *AccountServiceImpl) unlinkRevert(ctx context.Context, transactionID string) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, transactionID)
+	if err == nil {
+		st.RevertedBy, st.UpdatedAt = "", time.Now().UTC()
+		err = s.states.Save(ctx, st)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "save adjustment state failed", "transaction_id", transactionID, "err", err)
+	}
+}
@@ -0,0 +1,217 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"sre/internal/domain"
+)
+
+// WithApprovalStatus sets the notification status that approves an
+// adjustment, domain.NotificationStatusApproved by default. Notifications
+// with any other status are ignored.
+func WithApprovalStatus(status string) AccountServiceOption {
+	return func(s *AccountServiceImpl) { s.approvalStatus = status }
+}
+
+// WithNotificationDedupe makes approval notifications idempotent per
+// transaction: notifications keeps the decision taken on each, and versions
+// the adjustment each account's fee comes from. A notification then applies
+// its own adjustment, unless that is older than the account's version or
+// already applied.
+func WithNotificationDedupe(notifications NotificationRepository, versions FeeVersionRepository) AccountServiceOption {
+	return func(s *AccountServiceImpl) {
+		s.notifications = notifications
+		s.versions = versions
+	}
+}
+
+// isApproval reports whether status approves an adjustment.
+func (s *AccountServiceImpl) isApproval(status string) bool {
+	approval := s.approvalStatus
+	if approval == "" {
+		approval = domain.NotificationStatusApproved
+	}
+	return strings.EqualFold(status, approval)
+}
+
+// isDuplicate reports whether a notification of n's transaction was
+// processed already, counting the delivery if so. An approval is not a
+// duplicate of notifications with other statuses, which were ignored.
+// Notifications without a transaction ID are never duplicates.
+func (s *AccountServiceImpl) isDuplicate(ctx context.Context, n domain.FeeNotification) (bool, error) {
+	if s.notifications == nil || n.TransactionID == "" {
+		return false, nil
+	}
+	rec, err := s.notifications.Get(ctx, n.TransactionID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if s.isApproval(n.Status) && !s.isApproval(rec.Status) {
+		return false, nil
+	}
+	rec.Deliveries++
+	rec.LastSeenAt = time.Now().UTC()
+	if err := s.notifications.Save(ctx, rec); err != nil {
+		slog.ErrorContext(ctx, "save notification failed", "transaction_id", n.TransactionID, "err", err)
+	}
+	slog.InfoContext(ctx, "notification processed", "transaction_id", n.TransactionID, "account_id", n.AccountID, "status", n.Status,
+		"decision", "duplicate", "reason", fmt.Sprintf("delivery %d; the first one was %s: %s", rec.Deliveries, rec.Decision, rec.Reason))
+	return true, nil
+}
+
+// adjustmentToApply decides what n does to the account's fee. Without fee
+// versions, the account gets its last adjustment unless that was cancelled.
+// With them, it gets the notified adjustment unless that is stale. The
+// adjustment is returned with decision domain.NotificationApplied.
+func (s *AccountServiceImpl) adjustmentToApply(ctx context.Context, n domain.FeeNotification) (v domain.FeeVersion, decision, reason string, err error) {
+	if s.versions == nil {
+		last, err := s.adjustmentRepo.GetLastByAccount(ctx, domain.Account{ID: n.AccountID})
+		if err != nil {
+			return v, "", "", err
+		}
+		v = domain.FeeVersion{AccountID: n.AccountID, TransactionID: last.TransactionID, NewFee: last.NewFee}
+		if last.TransactionID != n.TransactionID && s.isCancelled(ctx, last.TransactionID) {
+			return v, domain.NotificationIgnored, fmt.Sprintf("last adjustment %s was cancelled; fee left unchanged", last.TransactionID), nil
+		}
+		return v, domain.NotificationApplied, "last adjustment of the account", nil
+	}
+	if v, err = s.locate(ctx, n); err != nil {
+		return v, "", "", err
+	}
+	cur, err := s.versions.Get(ctx, n.AccountID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return v, domain.NotificationApplied, "no adjustment of the account was applied before", nil
+	case err != nil:
+		return v, "", "", err
+	case cur.TransactionID == v.TransactionID:
+		return v, domain.NotificationStale, "adjustment is already applied", nil
+	}
+	c, by := v.Compare(cur)
+	switch {
+	case by == "":
+		return v, domain.NotificationApplied, fmt.Sprintf("order against applied adjustment %s unknown; the notified one wins", cur.TransactionID), nil
+	case c < 0:
+		return v, domain.NotificationStale, fmt.Sprintf("older than applied adjustment %s by %s", cur.TransactionID, by), nil
+	case c == 0:
+		return v, domain.NotificationApplied, fmt.Sprintf("as recent as applied adjustment %s by %s", cur.TransactionID, by), nil
+	}
+	return v, domain.NotificationApplied, fmt.Sprintf("newer than applied adjustment %s by %s", cur.TransactionID, by), nil
+}
+
+// trackApproval marks the adjustment n notifies approved in the ledger.
+// Notifications are not authenticated, so only adjustments of the account
+// that the ledger or the adjustment states already know are tracked.
+func (s *AccountServiceImpl) trackApproval(ctx context.Context, n domain.FeeNotification) {
+	if s.ledger == nil || n.TransactionID == "" || !s.knownAdjustment(ctx, n) {
+		return
+	}
+	s.track(ctx, n.AccountID, n.TransactionID, func(e *domain.FeeHistoryEntry) {
+		now := time.Now().UTC()
+		e.ApprovedAt = &now
+		if e.Status != domain.FeeApplied {
+			e.Status = domain.FeeApproved
+		}
+	})
+}
+
+// knownAdjustment reports whether n names an adjustment of its account that
+// this API sent or tracks.
+func (s *AccountServiceImpl) knownAdjustment(ctx context.Context, n domain.FeeNotification) bool {
+	if s.states != nil {
+		if st, err := s.states.Get(ctx, n.TransactionID); err == nil {
+			return st.AccountID == n.AccountID
+		}
+	}
+	entries, err := s.ledger.List(ctx, n.AccountID)
+	if err != nil {
+		slog.WarnContext(ctx, "read fee ledger failed", "account_id", n.AccountID, "err", err)
+		return false
+	}
+	for _, e := range entries {
+		if e.TransactionID == n.TransactionID {
+			return true
+		}
+	}
+	return false
+}
+
+// locate finds the adjustment n notifies in the backend history of its
+// account, and its request time in the adjustment states. An adjustment the
+// backend does not list yet is known from its state only.
+func (s *AccountServiceImpl) locate(ctx context.Context, n domain.FeeNotification) (domain.FeeVersion, error) {
+	v := domain.FeeVersion{AccountID: n.AccountID, TransactionID: n.TransactionID}
+	list, err := s.adjustmentRepo.AllByAccount(ctx, domain.Account{ID: n.AccountID})
+	if err != nil {
+		return v, err
+	}
+	// A notification without a transaction ID stands for the last
+	// adjustment, as it always did.
+	if v.TransactionID == "" && len(list) > 0 {
+		v.TransactionID = list[len(list)-1].TransactionID
+	}
+	found := false
+	for i, a := range list {
+		if a.TransactionID == v.TransactionID {
+			v.Sequence, v.NewFee, found = i+1, a.NewFee, true
+			break
+		}
+	}
+	if s.states != nil {
+		if st, err := s.states.Get(ctx, v.TransactionID); err == nil {
+			v.RequestedAt = &st.RequestedAt
+			if !found {
+				v.NewFee, found = st.NewFee, true
+			}
+		}
+	}
+	if !found {
+		return v, fmt.Errorf("tariff adjustment %q of account %q: %w", v.TransactionID, n.AccountID, domain.ErrNotFound)
+	}
+	return v, nil
+}
+
+// settle makes v the fee version of its account once its fee is written.
+// Until then, a redelivery of n applies it again.
+func (s *AccountServiceImpl) settle(ctx context.Context, v domain.FeeVersion) error {
+	if s.versions == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	v.AppliedAt = &now
+	return s.versions.Save(ctx, v)
+}
+
+// decide logs the decision taken on n and records it, so that later
+// deliveries of n's transaction are duplicates. A record that cannot be
+// written is only logged: a redelivery is then decided again, and the fee
+// version makes it stale if n was applied.
+func (s *AccountServiceImpl) decide(ctx context.Context, n domain.FeeNotification, decision, reason string) {
+	slog.InfoContext(ctx, "notification processed", "transaction_id", n.TransactionID, "account_id", n.AccountID, "status", n.Status,
+		"decision", decision, "reason", reason)
+	if s.notifications == nil || n.TransactionID == "" {
+		return
+	}
+	now := time.Now().UTC()
+	rec := domain.ProcessedNotification{
+		TransactionID: n.TransactionID,
+		AccountID:     n.AccountID,
+		Status:        n.Status,
+		Decision:      decision,
+		Reason:        reason,
+		Deliveries:    1,
+		ProcessedAt:   now,
+		LastSeenAt:    now,
+	}
+	if err := s.notifications.Save(ctx, rec); err != nil {
+		slog.ErrorContext(ctx, "save notification failed", "transaction_id", n.TransactionID, "err", err)
+	}
+}
@@ -0,0 +1,320 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sre/internal/domain"
+)
+
+// memAdjustments is the backend history of each account, oldest first.
+type memAdjustments map[string][]domain.TariffAdjustmentRequest
+
+func (m memAdjustments) Create(ctx context.Context, input domain.TariffAdjustmentRequest, callbackURL string) error {
+	m[input.AccountID] = append(m[input.AccountID], input)
+	return nil
+}
+
+func (m memAdjustments) GetLastByAccount(ctx context.Context, acc domain.Account) (*domain.TariffAdjustmentRequest, error) {
+	list := m[acc.ID]
+	if len(list) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return &list[len(list)-1], nil
+}
+
+func (m memAdjustments) AllByAccount(ctx context.Context, acc domain.Account) ([]domain.TariffAdjustmentRequest, error) {
+	return m[acc.ID], nil
+}
+
+type memAccounts map[string]float64
+
+func (m memAccounts) UpdateFee(ctx context.Context, acc domain.Account, newFee float64) error {
+	m[acc.ID] = newFee
+	return nil
+}
+
+func (m memAccounts) Get(ctx context.Context, acc domain.Account) (domain.Account, error) {
+	fee, ok := m[acc.ID]
+	if !ok {
+		return acc, domain.ErrNotFound
+	}
+	return domain.Account{ID: acc.ID, MonthlyFee: fee}, nil
+}
+
+type memStates map[string]domain.AdjustmentState
+
+func (m memStates) Save(ctx context.Context, st domain.AdjustmentState) error {
+	m[st.TransactionID] = st
+	return nil
+}
+
+func (m memStates) Get(ctx context.Context, transactionID string) (domain.AdjustmentState, error) {
+	st, ok := m[transactionID]
+	if !ok {
+		return st, domain.ErrNotFound
+	}
+	return st, nil
+}
+
+type memVersions map[string]domain.FeeVersion
+
+func (m memVersions) Save(ctx context.Context, v domain.FeeVersion) error {
+	m[v.AccountID] = v
+	return nil
+}
+
+func (m memVersions) Get(ctx context.Context, accountID string) (domain.FeeVersion, error) {
+	v, ok := m[accountID]
+	if !ok {
+		return v, domain.ErrNotFound
+	}
+	return v, nil
+}
+
+type memNotifications map[string]domain.ProcessedNotification
+
+func (m memNotifications) Save(ctx context.Context, n domain.ProcessedNotification) error {
+	m[n.TransactionID] = n
+	return nil
+}
+
+func (m memNotifications) Get(ctx context.Context, transactionID string) (domain.ProcessedNotification, error) {
+	n, ok := m[transactionID]
+	if !ok {
+		return n, domain.ErrNotFound
+	}
+	return n, nil
+}
+
+func TestIsDuplicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored *domain.ProcessedNotification
+		n      domain.FeeNotification
+		want   bool
+	}{
+		{
+			name: "first delivery",
+			n:    domain.FeeNotification{TransactionID: "tx-1", AccountID: "acc-1", Status: "approved"},
+		},
+		{
+			name: "no transaction ID",
+			n:    domain.FeeNotification{AccountID: "acc-1", Status: "approved"},
+		},
+		{
+			name:   "approval delivered again",
+			stored: &domain.ProcessedNotification{TransactionID: "tx-1", Status: "approved", Decision: domain.NotificationApplied, Deliveries: 1},
+			n:      domain.FeeNotification{TransactionID: "tx-1", AccountID: "acc-1", Status: "APPROVED"},
+			want:   true,
+		},
+		{
+			name:   "other status after the approval",
+			stored: &domain.ProcessedNotification{TransactionID: "tx-1", Status: "approved", Decision: domain.NotificationApplied, Deliveries: 1},
+			n:      domain.FeeNotification{TransactionID: "tx-1", AccountID: "acc-1", Status: "rejected"},
+			want:   true,
+		},
+		{
+			name:   "approval after an ignored status",
+			stored: &domain.ProcessedNotification{TransactionID: "tx-1", Status: "pending", Decision: domain.NotificationIgnored, Deliveries: 1},
+			n:      domain.FeeNotification{TransactionID: "tx-1", AccountID: "acc-1", Status: "approved"},
+		},
+		{
+			name:   "other status after an ignored one",
+			stored: &domain.ProcessedNotification{TransactionID: "tx-1", Status: "pending", Decision: domain.NotificationIgnored, Deliveries: 1},
+			n:      domain.FeeNotification{TransactionID: "tx-1", AccountID: "acc-1", Status: "rejected"},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifications := memNotifications{}
+			if tt.stored != nil {
+				notifications[tt.stored.TransactionID] = *tt.stored
+			}
+			s := &AccountServiceImpl{notifications: notifications}
+			got, err := s.isDuplicate(context.Background(), tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isDuplicate = %v, want %v", got, tt.want)
+			}
+			if tt.want && notifications[tt.n.TransactionID].Deliveries != 2 {
+				t.Errorf("duplicate counted as delivery %d, want 2", notifications[tt.n.TransactionID].Deliveries)
+			}
+		})
+	}
+}
+
+func TestAdjustmentToApply(t *testing.T) {
+	t1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	history := memAdjustments{"acc-1": {
+		{TransactionID: "tx-1", AccountID: "acc-1", NewFee: 10},
+		{TransactionID: "tx-2", AccountID: "acc-1", NewFee: 20},
+	}}
+	states := memStates{
+		"tx-2": {TransactionID: "tx-2", AccountID: "acc-1", NewFee: 20, RequestedAt: t1},
+		"tx-3": {TransactionID: "tx-3", AccountID: "acc-1", NewFee: 30, RequestedAt: t2},
+	}
+	tests := []struct {
+		name     string
+		current  *domain.FeeVersion
+		tx       string
+		decision string
+		fee      float64
+		notFound bool
+	}{
+		{
+			name:     "no version yet",
+			tx:       "tx-1",
+			decision: domain.NotificationApplied,
+			fee:      10,
+		},
+		{
+			name:     "already applied",
+			current:  &domain.FeeVersion{AccountID: "acc-1", TransactionID: "tx-2", Sequence: 2},
+			tx:       "tx-2",
+			decision: domain.NotificationStale,
+			fee:      20,
+		},
+		{
+			name:     "newer by sequence",
+			current:  &domain.FeeVersion{AccountID: "acc-1", TransactionID: "tx-1", Sequence: 1},
+			tx:       "tx-2",
+			decision: domain.NotificationApplied,
+			fee:      20,
+		},
+		{
+			name:     "older by sequence",
+			current:  &domain.FeeVersion{AccountID: "acc-1", TransactionID: "tx-2", Sequence: 2},
+			tx:       "tx-1",
+			decision: domain.NotificationStale,
+			fee:      10,
+		},
+		{
+			name:     "not listed yet, newer by request time",
+			current:  &domain.FeeVersion{AccountID: "acc-1", TransactionID: "tx-2", Sequence: 2, RequestedAt: &t1},
+			tx:       "tx-3",
+			decision: domain.NotificationApplied,
+			fee:      30,
+		},
+		{
+			name:     "not comparable",
+			current:  &domain.FeeVersion{AccountID: "acc-1", TransactionID: "tx-0"},
+			tx:       "tx-1",
+			decision: domain.NotificationApplied,
+			fee:      10,
+		},
+		{
+			name:     "no transaction ID stands for the last adjustment",
+			current:  &domain.FeeVersion{AccountID: "acc-1", TransactionID: "tx-1", Sequence: 1},
+			decision: domain.NotificationApplied,
+			fee:      20,
+		},
+		{
+			name:     "unknown adjustment",
+			tx:       "tx-9",
+			notFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := memVersions{}
+			if tt.current != nil {
+				versions[tt.current.AccountID] = *tt.current
+			}
+			s := &AccountServiceImpl{adjustmentRepo: history, states: states, versions: versions}
+			n := domain.FeeNotification{TransactionID: tt.tx, AccountID: "acc-1", Status: "approved"}
+			v, decision, reason, err := s.adjustmentToApply(context.Background(), n)
+			if tt.notFound {
+				if !errors.Is(err, domain.ErrNotFound) {
+					t.Fatalf("adjustmentToApply error = %v, want domain.ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision != tt.decision || v.NewFee != tt.fee {
+				t.Errorf("adjustmentToApply = %s with fee %g (%s), want %s with fee %g", decision, v.NewFee, reason, tt.decision, tt.fee)
+			}
+			if reason == "" {
+				t.Error("adjustmentToApply gave no reason")
+			}
+		})
+	}
+}
+
+func TestAdjustmentToApplyWithoutVersions(t *testing.T) {
+	history := memAdjustments{"acc-1": {
+		{TransactionID: "tx-1", AccountID: "acc-1", NewFee: 10},
+		{TransactionID: "tx-2", AccountID: "acc-1", NewFee: 20},
+	}}
+	tests := []struct {
+		name     string
+		states   memStates
+		tx       string
+		decision string
+	}{
+		{"last adjustment", memStates{}, "tx-2", domain.NotificationApplied},
+		{"older notification gets the last fee", memStates{}, "tx-1", domain.NotificationApplied},
+		{"last adjustment cancelled", memStates{"tx-2": {TransactionID: "tx-2", Status: domain.FeeCancelled}}, "tx-1", domain.NotificationIgnored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AccountServiceImpl{adjustmentRepo: history, states: tt.states}
+			n := domain.FeeNotification{TransactionID: tt.tx, AccountID: "acc-1", Status: "approved"}
+			v, decision, _, err := s.adjustmentToApply(context.Background(), n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision != tt.decision || v.TransactionID != "tx-2" || v.NewFee != 20 {
+				t.Errorf("adjustmentToApply = %s of %s with fee %g, want %s of tx-2 with fee 20", decision, v.TransactionID, v.NewFee, tt.decision)
+			}
+		})
+	}
+}
+
+func TestUpdateFee(t *testing.T) {
+	accounts := memAccounts{"acc-1": 5}
+	notifications := memNotifications{}
+	versions := memVersions{}
+	history := memAdjustments{"acc-1": {
+		{TransactionID: "tx-1", AccountID: "acc-1", NewFee: 10},
+		{TransactionID: "tx-2", AccountID: "acc-1", NewFee: 20},
+	}}
+	s := NewAccountService(accounts, history, nil, "", WithNotificationDedupe(notifications, versions)).(*AccountServiceImpl)
+	steps := []struct {
+		tx, status string
+		fee        float64
+		decision   string
+	}{
+		{"tx-2", "pending", 5, domain.NotificationIgnored},
+		{"tx-2", "approved", 20, domain.NotificationApplied},
+		{"tx-2", "approved", 20, domain.NotificationApplied},
+		{"tx-1", "approved", 20, domain.NotificationStale},
+		{"tx-1", "approved", 20, domain.NotificationStale},
+	}
+	for i, st := range steps {
+		n := domain.FeeNotification{TransactionID: st.tx, AccountID: "acc-1", Status: st.status}
+		if err := s.UpdateFee(context.Background(), n); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if accounts["acc-1"] != st.fee {
+			t.Errorf("step %d: fee %g, want %g", i, accounts["acc-1"], st.fee)
+		}
+		if got := notifications[st.tx].Decision; got != st.decision {
+			t.Errorf("step %d: decision %q, want %q", i, got, st.decision)
+		}
+	}
+	if got := notifications["tx-2"].Deliveries; got != 2 {
+		t.Errorf("tx-2 approval counted %d deliveries, want 2", got)
+	}
+	if got := versions["acc-1"].TransactionID; got != "tx-2" {
+		t.Errorf("fee version %q, want tx-2", got)
+	}
+}
@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"sre/internal/domain"
+)
+
+// largeFeeChange is the relative fee change a preview warns about.
+const largeFeeChange = 0.5
+
+// Preview streams the catalog once, feeding the current accounts to one
+// report and the adjusted ones to another, and checks each item against the
+// account it targets.
+func (b *BulkAdjuster) Preview(ctx context.Context, req domain.BulkAdjustmentRequest, params domain.ReportParams) (domain.AdjustmentPreview, error) {
+	if err := params.Validate(); err != nil {
+		return domain.AdjustmentPreview{}, err
+	}
+	items, err := b.expand(ctx, req)
+	if err != nil {
+		return domain.AdjustmentPreview{}, err
+	}
+	// Items of one account are sent in order, so the last one wins.
+	newFees := make(map[string]float64, len(items))
+	perAccount := make(map[string]int, len(items))
+	for _, it := range items {
+		newFees[it.AccountID] = it.NewFee
+		perAccount[it.AccountID]++
+	}
+	current, projected := newReportAggregator(params), newReportAggregator(params)
+	found := make(map[string]domain.Account, len(newFees))
+	err = b.search.StreamAccountsByTerm(ctx, "", func(a domain.Account) error {
+		current.add(a)
+		if fee, ok := newFees[a.ID]; ok {
+			found[a.ID] = a
+			a.MonthlyFee = fee
+		}
+		projected.add(a)
+		return nil
+	})
+	if err != nil {
+		return domain.AdjustmentPreview{}, err
+	}
+
+	p := domain.AdjustmentPreview{Valid: true, Items: make([]domain.PreviewItem, 0, len(items))}
+	for _, it := range items {
+		item := previewItem(it, found, perAccount[it.AccountID])
+		if len(item.Errors) > 0 {
+			p.Valid = false
+		}
+		p.Items = append(p.Items, item)
+	}
+	if p.Current, err = current.report(); err != nil {
+		return domain.AdjustmentPreview{}, err
+	}
+	if p.Projected, err = projected.report(); err != nil {
+		return domain.AdjustmentPreview{}, err
+	}
+	d := diffReports(rankingSnapshot(p.Current), rankingSnapshot(p.Projected))
+	p.EnteredTop, p.LeftTop, p.FeeChanges = d.EnteredTop, d.LeftTop, d.FeeChanges
+	slog.InfoContext(ctx, "tariff adjustments previewed", "items", len(items), "accounts", len(newFees), "valid", p.Valid)
+	return p, nil
+}
+
+// previewItem runs the validation rules of it; adjustments is the number of
+// items of the same account.
+func previewItem(it domain.BulkAdjustmentItem, found map[string]domain.Account, adjustments int) domain.PreviewItem {
+	item := domain.PreviewItem{Index: it.Index, AccountID: it.AccountID, NewFee: it.NewFee}
+	if err := it.Request().Validate(); err != nil {
+		item.Errors = append(item.Errors, err.Error())
+	}
+	acc, ok := found[it.AccountID]
+	if !ok {
+		item.Errors = append(item.Errors, "account not found")
+	} else {
+		oldFee := acc.MonthlyFee
+		delta := math.Round((it.NewFee-oldFee)*100) / 100
+		item.Name, item.Type, item.OldFee, item.Delta = acc.Name, acc.Type, &oldFee, &delta
+		switch {
+		case delta == 0:
+			item.Warnings = append(item.Warnings, "new fee equals the current fee")
+		case oldFee > 0 && math.Abs(delta)/oldFee > largeFeeChange:
+			item.Warnings = append(item.Warnings, fmt.Sprintf("fee changes by more than %.0f%%", largeFeeChange*100))
+		}
+	}
+	if adjustments > 1 {
+		item.Warnings = append(item.Warnings, fmt.Sprintf("account has %d adjustments in this request; the last one wins", adjustments))
+	}
+	return item
+}
+
+// rankingSnapshot wraps rep for diffReports, ranking by its custom top when
+// it has one.
+func rankingSnapshot(rep domain.Report) domain.ReportSnapshot {
+	if rep.Top != nil {
+		rep.Top100ByFee = rep.Top.Accounts
+	}
+	return domain.ReportSnapshot{Report: rep}
+}
+
+// PreviewTariffAdjustment computes what input would do without sending it.
+func (s *AccountServiceImpl) PreviewTariffAdjustment(ctx context.Context, input domain.TariffAdjustmentRequest, params domain.ReportParams) (domain.AdjustmentPreview, error) {
+	if s.bulk == nil {
+		return domain.AdjustmentPreview{}, fmt.Errorf("%w: dry runs are not enabled", domain.ErrInvalidBulkRequest)
+	}
+	return s.bulk.Preview(ctx, domain.BulkAdjustmentRequest{
+		Items: []domain.BulkAdjustmentInput{{AccountID: input.AccountID, NewFee: input.NewFee}},
+	}, params)
+}
@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sre/internal/domain"
+)
+
+// WithSagas sends adjustments to the backend through sagas run by o, which
+// outlive crashes and void adjustments that cannot be sent. Voiding needs
+// WithAdjustmentStates.
+//
+// Create and BeginFlow run in parallel: both are idempotent by transaction
+// ID, and neither needs the other's result. The backend cannot undo either,
+// so both are compensated by voiding the adjustment locally: it is cancelled,
+// its approval notification is ignored and its fee never applied.
+func WithSagas(o *SagaOrchestrator) AccountServiceOption {
+	return func(s *AccountServiceImpl) {
+		s.sagas = o
+		o.steps = []sagaStep{
+			{name: "create_adjustment", stage: 0, run: s.createAdjustment, compensate: s.voidAdjustment},
+			{name: "begin_flow", stage: 0, run: s.beginFlow, compensate: s.voidAdjustment},
+		}
+		o.reopen = s.reopenAdjustment
+	}
+}
+
+func (s *AccountServiceImpl) createAdjustment(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.flags.Get().AdjustmentTimeout.Std())
+	defer cancel()
+	return s.adjustmentRepo.Create(ctx, input, s.callbackURL)
+}
+
+func (s *AccountServiceImpl) beginFlow(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.flags.Get().AdjustmentTimeout.Std())
+	defer cancel()
+	return s.flowProcessor.BeginFlow(ctx, input, s.callbackURL)
+}
+
+// voidAdjustment cancels the adjustment of a failed saga. It cannot once
+// the adjustment was approved, as its fee may already be applied.
+func (s *AccountServiceImpl) voidAdjustment(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	if s.states == nil {
+		return errors.New("adjustment states are not enabled")
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, input.TransactionID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		now := time.Now().UTC()
+		st = domain.AdjustmentState{
+			TransactionID: input.TransactionID,
+			AccountID:     input.AccountID,
+			NewFee:        input.NewFee,
+			RequestedBy:   input.RequestedBy,
+			RequestedAt:   now,
+		}
+	case err != nil:
+		return err
+	case st.Status == domain.FeeCancelled:
+		return nil
+	case st.Status != domain.FeeRequested:
+		return fmt.Errorf("%w: it is %s", domain.ErrNotCancellable, st.Status)
+	}
+	return s.markCancelled(ctx, &st, true)
+}
+
+// reopenAdjustment undoes the voiding of an adjustment that is sent again.
+// An adjustment a client cancelled stays cancelled.
+func (s *AccountServiceImpl) reopenAdjustment(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	if s.states == nil {
+		return nil
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st, err := s.states.Get(ctx, input.TransactionID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case st.Status != domain.FeeCancelled:
+		return nil
+	case !st.Voided:
+		return fmt.Errorf("tariff adjustment %s was cancelled", input.TransactionID)
+	}
+	if err := s.record(ctx, domain.AuditRecord{
+		Action:        domain.AuditAdjustmentRequested,
+		AccountID:     st.AccountID,
+		TransactionID: st.TransactionID,
+		OldFee:        st.OldFee,
+		NewFee:        &st.NewFee,
+		Status:        "retried",
+		RevertOf:      st.RevertOf,
+	}); err != nil {
+		return err
+	}
+	st.Status, st.Voided, st.UpdatedAt = domain.FeeRequested, false, time.Now().UTC()
+	if err := s.states.Save(ctx, st); err != nil {
+		return err
+	}
+	s.track(ctx, st.AccountID, st.TransactionID, func(e *domain.FeeHistoryEntry) {
+		e.Status = domain.FeeRequested
+		e.CancelledAt = nil
+	})
+	return nil
+}
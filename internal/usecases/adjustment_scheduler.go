@@ -0,0 +1,241 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"sre/internal/auth"
+	"sre/internal/domain"
+)
+
+// Retry policy of scheduled adjustments that fail to send.
+const (
+	scheduleMaxAttempts  = 5
+	scheduleRetryBackoff = 30 * time.Second
+)
+
+// NewAdjustmentScheduler creates a scheduler that keeps its adjustments in
+// repo, so they survive restarts. It sends nothing until an AccountService
+// adopts it with WithScheduler and Run is called.
+func NewAdjustmentScheduler(repo ScheduledAdjustmentRepository) *AdjustmentScheduler {
+	return &AdjustmentScheduler{repo: repo, inflight: make(map[string]bool)}
+}
+
+// AdjustmentScheduler holds tariff adjustments until their effective time.
+type AdjustmentScheduler struct {
+	repo ScheduledAdjustmentRepository
+	send func(ctx context.Context, a domain.ScheduledAdjustment) error
+
+	// mu serializes state changes, so a cancel cannot race a send.
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+// Run sends due adjustments every interval until ctx is done.
+func (s *AdjustmentScheduler) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s.sendDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *AdjustmentScheduler) add(ctx context.Context, a domain.ScheduledAdjustment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repo.Save(ctx, a)
+}
+
+func (s *AdjustmentScheduler) list(ctx context.Context, accountID string) ([]domain.ScheduledAdjustment, error) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []domain.ScheduledAdjustment{}
+	for _, a := range all {
+		if a.AccountID == accountID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (s *AdjustmentScheduler) cancel(ctx context.Context, accountID, id string) (domain.ScheduledAdjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return a, err
+	}
+	if a.AccountID != accountID {
+		return a, fmt.Errorf("scheduled adjustment %q: %w", id, domain.ErrNotFound)
+	}
+	if a.Status != domain.SchedulePending || s.inflight[id] {
+		return a, fmt.Errorf("%w: %s", domain.ErrScheduleNotPending, a.Status)
+	}
+	now := time.Now().UTC()
+	a.Status, a.CancelledAt = domain.ScheduleCancelled, &now
+	return a, s.repo.Save(ctx, a)
+}
+
+// sendDue sends the pending adjustments due at now, one at a time. Each
+// attempt is saved before the send, with its retry time, so a crash during
+// the send leads to a retry rather than a loss; the backend deduplicates by
+// transaction ID.
+func (s *AdjustmentScheduler) sendDue(ctx context.Context, now time.Time) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "list scheduled adjustments failed", "err", err)
+		return
+	}
+	for _, a := range all {
+		if a.Status != domain.SchedulePending || a.NextAttemptAt.After(now) || ctx.Err() != nil {
+			continue
+		}
+		a, ok := s.begin(ctx, a.ID, now)
+		if !ok {
+			continue
+		}
+		err := s.send(ctx, a)
+		s.finish(ctx, a, err)
+	}
+}
+
+// begin claims a due adjustment for an attempt.
+func (s *AdjustmentScheduler) begin(ctx context.Context, id string, now time.Time) (domain.ScheduledAdjustment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, err := s.repo.Get(ctx, id)
+	if err != nil || a.Status != domain.SchedulePending {
+		return a, false
+	}
+	a.Attempts++
+	a.NextAttemptAt = now.Add(scheduleRetryBackoff << (a.Attempts - 1)).UTC()
+	if err := s.repo.Save(ctx, a); err != nil {
+		slog.ErrorContext(ctx, "save scheduled adjustment failed", "id", id, "err", err)
+		return a, false
+	}
+	s.inflight[id] = true
+	return a, true
+}
+
+func (s *AdjustmentScheduler) finish(ctx context.Context, a domain.ScheduledAdjustment, sendErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, a.ID)
+	switch {
+	case sendErr == nil:
+		now := time.Now().UTC()
+		a.Status, a.StartedAt, a.LastError = domain.ScheduleStarted, &now, ""
+		slog.InfoContext(ctx, "scheduled adjustment started", "id", a.ID, "account_id", a.AccountID, "attempts", a.Attempts)
+	case a.Attempts >= scheduleMaxAttempts:
+		a.Status, a.LastError = domain.ScheduleFailed, sendErr.Error()
+		slog.ErrorContext(ctx, "scheduled adjustment failed", "id", a.ID, "account_id", a.AccountID, "attempts", a.Attempts, "err", sendErr)
+	default:
+		a.LastError = sendErr.Error()
+		slog.WarnContext(ctx, "scheduled adjustment will be retried", "id", a.ID, "account_id", a.AccountID, "next_attempt_at", a.NextAttemptAt, "err", sendErr)
+	}
+	if err := s.repo.Save(ctx, a); err != nil {
+		slog.ErrorContext(ctx, "save scheduled adjustment failed", "id", a.ID, "err", err)
+	}
+}
+
+// errSchedulingDisabled is returned by AccountServiceImpl without a scheduler.
+var errSchedulingDisabled = fmt.Errorf("%w: scheduling is not enabled", domain.ErrInvalidSchedule)
+
+// ScheduleTariffAdjustment holds input until effectiveAt, which must be in
+// the future; its transaction ID identifies the scheduled adjustment.
+func (s *AccountServiceImpl) ScheduleTariffAdjustment(ctx context.Context, input domain.TariffAdjustmentRequest, effectiveAt time.Time) (domain.ScheduledAdjustment, error) {
+	if s.scheduler == nil {
+		return domain.ScheduledAdjustment{}, errSchedulingDisabled
+	}
+	if err := input.Validate(); err != nil {
+		return domain.ScheduledAdjustment{}, err
+	}
+	now := time.Now().UTC()
+	if !effectiveAt.After(now) {
+		return domain.ScheduledAdjustment{}, fmt.Errorf("%w: effective_at must be in the future", domain.ErrInvalidSchedule)
+	}
+	a := domain.ScheduledAdjustment{
+		ID:            input.TransactionID,
+		AccountID:     input.AccountID,
+		NewFee:        input.NewFee,
+		EffectiveAt:   effectiveAt.UTC(),
+		RequestedBy:   requestedBy(ctx),
+		CreatedAt:     now,
+		Status:        domain.SchedulePending,
+		NextAttemptAt: effectiveAt.UTC(),
+	}
+	slog.InfoContext(ctx, "scheduling tariff adjustment", "adjustment", a)
+	if err := s.record(ctx, domain.AuditRecord{
+		Action:        domain.AuditAdjustmentScheduled,
+		AccountID:     a.AccountID,
+		TransactionID: a.ID,
+		NewFee:        &a.NewFee,
+		EffectiveAt:   &a.EffectiveAt,
+	}); err != nil {
+		return a, err
+	}
+	if err := s.scheduler.add(ctx, a); err != nil {
+		return a, err
+	}
+	s.track(ctx, a.AccountID, a.ID, func(e *domain.FeeHistoryEntry) {
+		e.NewFee = a.NewFee
+		e.Status = domain.FeeScheduled
+		e.RequestedBy = a.RequestedBy
+		e.RequestedAt = &now
+		e.EffectiveAt = &a.EffectiveAt
+	})
+	return a, nil
+}
+
+func (s *AccountServiceImpl) ListScheduledAdjustments(ctx context.Context, accountID string) ([]domain.ScheduledAdjustment, error) {
+	if s.scheduler == nil {
+		return []domain.ScheduledAdjustment{}, nil
+	}
+	return s.scheduler.list(ctx, accountID)
+}
+
+// CancelScheduledAdjustment cancels a pending scheduled adjustment of
+// accountID. It fails with domain.ErrScheduleNotPending once the adjustment
+// is being sent or was sent.
+func (s *AccountServiceImpl) CancelScheduledAdjustment(ctx context.Context, accountID, id string) (domain.ScheduledAdjustment, error) {
+	if s.scheduler == nil {
+		return domain.ScheduledAdjustment{}, fmt.Errorf("scheduled adjustment %q: %w", id, domain.ErrNotFound)
+	}
+	a, err := s.scheduler.cancel(ctx, accountID, id)
+	if err != nil {
+		return a, err
+	}
+	slog.InfoContext(ctx, "scheduled tariff adjustment cancelled", "id", id, "account_id", accountID)
+	s.track(ctx, accountID, id, func(e *domain.FeeHistoryEntry) { e.Status = domain.FeeCancelled })
+	// The cancellation already happened, so a failed audit record is only
+	// logged by record.
+	_ = s.record(ctx, domain.AuditRecord{
+		Action:        domain.AuditScheduleCancelled,
+		AccountID:     accountID,
+		TransactionID: id,
+		NewFee:        &a.NewFee,
+	})
+	return a, nil
+}
+
+// sendScheduled sends a due scheduled adjustment on behalf of the client that
+// scheduled it, waiting for both backend calls whatever the pipeline mode.
+func (s *AccountServiceImpl) sendScheduled(ctx context.Context, a domain.ScheduledAdjustment) error {
+	ctx = auth.WithClient(ctx, auth.Client{Name: a.RequestedBy})
+	input := a.Request()
+	slog.InfoContext(ctx, "sending scheduled tariff adjustment", "input", input, "effective_at", a.EffectiveAt)
+	if err := s.recordRequest(ctx, input); err != nil {
+		return err
+	}
+	return s.startPipeline(ctx, input, s.flags.Get().AdjustmentTimeout.Std())
+}
@@ -0,0 +1,52 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"sre/internal/domain"
+)
+
+// Page sizes of audit queries.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+var _ AuditService = (*AuditServiceImpl)(nil)
+
+// AuditService reads the audit log.
+type AuditService interface {
+	Query(ctx context.Context, q domain.AuditQuery) (domain.AuditPage, error)
+}
+
+// NewAuditService creates an AuditService over log.
+func NewAuditService(log AuditLog) *AuditServiceImpl {
+	return &AuditServiceImpl{log: log}
+}
+
+type AuditServiceImpl struct {
+	log AuditLog
+}
+
+// Query returns a page of at most q.Limit records, 100 by default.
+func (s *AuditServiceImpl) Query(ctx context.Context, q domain.AuditQuery) (domain.AuditPage, error) {
+	if q.Limit == 0 {
+		q.Limit = defaultAuditLimit
+	}
+	if q.Limit < 0 || q.Limit > maxAuditLimit {
+		return domain.AuditPage{}, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidAuditQuery, maxAuditLimit)
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return domain.AuditPage{}, fmt.Errorf("%w: from must be before to", domain.ErrInvalidAuditQuery)
+	}
+	records, err := s.log.Query(ctx, q)
+	if err != nil {
+		return domain.AuditPage{}, err
+	}
+	page := domain.AuditPage{Records: records}
+	if len(records) == q.Limit {
+		page.NextAfter = records[len(records)-1].Seq
+	}
+	return page, nil
+}
@@ -0,0 +1,359 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sre/internal/domain"
+	"sre/internal/query"
+)
+
+var _ BulkAdjustmentService = (*BulkAdjuster)(nil)
+
+// BulkAdjustmentService runs many tariff adjustments as one job. Jobs are
+// only visible to the client that submitted them.
+type BulkAdjustmentService interface {
+	Submit(ctx context.Context, owner domain.JobOwner, req domain.BulkAdjustmentRequest) (domain.BulkAdjustmentJob, error)
+	Get(ctx context.Context, clientID, id string) (domain.BulkAdjustmentJob, error)
+	// Preview computes what req would do, projected on a report with params,
+	// without sending anything.
+	Preview(ctx context.Context, req domain.BulkAdjustmentRequest, params domain.ReportParams) (domain.AdjustmentPreview, error)
+	// RetryFailed sends the failed items of a finished job again, with their
+	// original transaction IDs.
+	RetryFailed(ctx context.Context, owner domain.JobOwner, id string) (domain.BulkAdjustmentJob, error)
+}
+
+// BulkAdjustmentConfig sizes bulk adjustment jobs.
+type BulkAdjustmentConfig struct {
+	// Parallelism bounds the adjustments being sent at once, across jobs.
+	Parallelism int
+	// MaxItems bounds the adjustments of one job.
+	MaxItems int
+	// MaxPerClient bounds the running jobs of one client.
+	MaxPerClient int
+	// TTL is how long a finished job is kept.
+	TTL time.Duration
+}
+
+// accountLockStripes is the number of locks in accountLocks.
+const accountLockStripes = 64
+
+// accountLocks serializes work on each account, with a fixed number of locks
+// shared by hash.
+type accountLocks [accountLockStripes]sync.Mutex
+
+func (l *accountLocks) of(accountID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(accountID))
+	return &l[h.Sum32()%accountLockStripes]
+}
+
+// NewBulkAdjuster creates a BulkAdjustmentService that expands rules over
+// the catalog of search. It sends nothing until an AccountService adopts it
+// with WithBulkAdjustments.
+func NewBulkAdjuster(search SearchService, cfg BulkAdjustmentConfig) *BulkAdjuster {
+	return &BulkAdjuster{
+		search: search,
+		cfg:    cfg,
+		slots:  make(chan struct{}, max(cfg.Parallelism, 1)),
+		jobs:   make(map[string]*domain.BulkAdjustmentJob),
+	}
+}
+
+// BulkAdjuster runs bulk adjustment jobs in memory.
+type BulkAdjuster struct {
+	search SearchService
+	cfg    BulkAdjustmentConfig
+	send   func(ctx context.Context, input domain.TariffAdjustmentRequest) error
+
+	// slots bounds the adjustments in flight; accounts orders them per account.
+	slots    chan struct{}
+	accounts accountLocks
+
+	mu   sync.Mutex
+	jobs map[string]*domain.BulkAdjustmentJob
+}
+
+// Run expires finished jobs until ctx is done.
+func (b *BulkAdjuster) Run(ctx context.Context) {
+	t := time.NewTicker(max(min(b.cfg.TTL/2, time.Minute), time.Second))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			b.expire(now)
+		}
+	}
+}
+
+func (b *BulkAdjuster) Submit(ctx context.Context, owner domain.JobOwner, req domain.BulkAdjustmentRequest) (domain.BulkAdjustmentJob, error) {
+	if b.send == nil {
+		return domain.BulkAdjustmentJob{}, fmt.Errorf("%w: bulk adjustments are not enabled", domain.ErrInvalidBulkRequest)
+	}
+	items, err := b.expand(ctx, req)
+	if err != nil {
+		return domain.BulkAdjustmentJob{}, err
+	}
+	for _, it := range items {
+		if err := it.Request().Validate(); err != nil {
+			return domain.BulkAdjustmentJob{}, fmt.Errorf("%w: item %d (account %s): %w", domain.ErrInvalidBulkRequest, it.Index, it.AccountID, err)
+		}
+	}
+	job := &domain.BulkAdjustmentJob{
+		ID:        uuid.NewString(),
+		ClientID:  owner.ID,
+		Quota:     owner.Quota,
+		Status:    domain.BulkRunning,
+		Rule:      req.Rule,
+		Total:     len(items),
+		Pending:   len(items),
+		Items:     items,
+		CreatedAt: time.Now().UTC(),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkActive(owner.Quota); err != nil {
+		return domain.BulkAdjustmentJob{}, err
+	}
+	b.jobs[job.ID] = job
+	slog.InfoContext(ctx, "bulk adjustment job started", "job_id", job.ID, "client_id", owner.ID, "items", job.Total)
+	go b.run(context.WithoutCancel(ctx), job, allIndexes(len(items)))
+	return bulkSnapshot(job), nil
+}
+
+// expand validates req and returns its items, with new transaction IDs.
+func (b *BulkAdjuster) expand(ctx context.Context, req domain.BulkAdjustmentRequest) ([]domain.BulkAdjustmentItem, error) {
+	if (req.Rule == nil) == (len(req.Items) == 0) {
+		return nil, fmt.Errorf("%w: give either items or a rule", domain.ErrInvalidBulkRequest)
+	}
+	var items []domain.BulkAdjustmentItem
+	if req.Rule != nil {
+		if err := req.Rule.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidBulkRequest, err)
+		}
+		expr, err := query.Parse(req.Rule.Query)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule query: %v", domain.ErrInvalidBulkRequest, err)
+		}
+		accounts, err := b.search.QueryAccounts(ctx, expr, []query.SortKey{{Field: query.FieldID}})
+		if err != nil {
+			return nil, err
+		}
+		if len(accounts) == 0 {
+			return nil, fmt.Errorf("%w: rule matches no accounts", domain.ErrInvalidBulkRequest)
+		}
+		for _, a := range accounts {
+			oldFee := a.MonthlyFee
+			items = append(items, domain.BulkAdjustmentItem{AccountID: a.ID, OldFee: &oldFee, NewFee: req.Rule.Apply(a.MonthlyFee)})
+		}
+	} else {
+		for _, in := range req.Items {
+			items = append(items, domain.BulkAdjustmentItem{AccountID: in.AccountID, NewFee: in.NewFee})
+		}
+	}
+	if len(items) > b.cfg.MaxItems {
+		return nil, fmt.Errorf("%w: %d adjustments, at most %d allowed", domain.ErrInvalidBulkRequest, len(items), b.cfg.MaxItems)
+	}
+	for i := range items {
+		it := &items[i]
+		if it.AccountID == "" {
+			return nil, fmt.Errorf("%w: item %d has no account_id", domain.ErrInvalidBulkRequest, i)
+		}
+		it.Index = i
+		it.TransactionID = uuid.NewString()
+		it.Status = domain.BulkItemPending
+	}
+	return items, nil
+}
+
+func (b *BulkAdjuster) Get(ctx context.Context, clientID, id string) (domain.BulkAdjustmentJob, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, err := b.lookup(clientID, id)
+	if err != nil {
+		return domain.BulkAdjustmentJob{}, err
+	}
+	return bulkSnapshot(job), nil
+}
+
+func (b *BulkAdjuster) RetryFailed(ctx context.Context, owner domain.JobOwner, id string) (domain.BulkAdjustmentJob, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, err := b.lookup(owner.ID, id)
+	if err != nil {
+		return domain.BulkAdjustmentJob{}, err
+	}
+	if !job.Finished() {
+		return domain.BulkAdjustmentJob{}, domain.ErrBulkJobRunning
+	}
+	if job.Failed == 0 {
+		return bulkSnapshot(job), nil
+	}
+	if err := b.checkActive(owner.Quota); err != nil {
+		return domain.BulkAdjustmentJob{}, err
+	}
+	var retry []int
+	for i := range job.Items {
+		if it := &job.Items[i]; it.Status == domain.BulkItemFailed {
+			it.Status, it.Error = domain.BulkItemPending, ""
+			retry = append(retry, i)
+		}
+	}
+	job.Status = domain.BulkRunning
+	job.Failed -= len(retry)
+	job.Pending += len(retry)
+	job.FinishedAt, job.ExpiresAt = nil, nil
+	slog.InfoContext(ctx, "bulk adjustment job retried", "job_id", id, "client_id", owner.ID, "items", len(retry))
+	go b.run(context.WithoutCancel(ctx), job, retry)
+	return bulkSnapshot(job), nil
+}
+
+// checkActive enforces MaxPerClient on the jobs of quota; the caller holds
+// b.mu.
+func (b *BulkAdjuster) checkActive(quota string) error {
+	active := 0
+	for _, j := range b.jobs {
+		if j.Quota == quota && !j.Finished() {
+			active++
+		}
+	}
+	if active >= b.cfg.MaxPerClient {
+		return domain.ErrTooManyJobs
+	}
+	return nil
+}
+
+// lookup finds a job of clientID; the caller holds b.mu.
+func (b *BulkAdjuster) lookup(clientID, id string) (*domain.BulkAdjustmentJob, error) {
+	job, ok := b.jobs[id]
+	if !ok || job.ClientID != clientID {
+		return nil, fmt.Errorf("bulk adjustment job %q: %w", id, domain.ErrNotFound)
+	}
+	return job, nil
+}
+
+// run sends the items of job at indexes. Each account's items go to one
+// goroutine, which sends them in order; the goroutines share the slots with
+// every other job.
+func (b *BulkAdjuster) run(ctx context.Context, job *domain.BulkAdjustmentJob, indexes []int) {
+	var order []string
+	byAccount := make(map[string][]int)
+	b.mu.Lock()
+	for _, i := range indexes {
+		acc := job.Items[i].AccountID
+		if _, ok := byAccount[acc]; !ok {
+			order = append(order, acc)
+		}
+		byAccount[acc] = append(byAccount[acc], i)
+	}
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, acc := range order {
+		wg.Add(1)
+		go func(acc string, indexes []int) {
+			defer wg.Done()
+			lock := b.accounts.of(acc)
+			lock.Lock()
+			defer lock.Unlock()
+			for _, i := range indexes {
+				b.slots <- struct{}{}
+				b.sendItem(ctx, job, i)
+				<-b.slots
+			}
+		}(acc, byAccount[acc])
+	}
+	wg.Wait()
+
+	b.mu.Lock()
+	now := time.Now().UTC()
+	expires := now.Add(b.cfg.TTL)
+	switch {
+	case job.Failed == 0:
+		job.Status = domain.BulkSucceeded
+	case job.Succeeded == 0:
+		job.Status = domain.BulkFailed
+	default:
+		job.Status = domain.BulkPartiallyFailed
+	}
+	job.FinishedAt, job.ExpiresAt = &now, &expires
+	status, succeeded, failed := job.Status, job.Succeeded, job.Failed
+	b.mu.Unlock()
+	slog.InfoContext(ctx, "bulk adjustment job finished", "job_id", job.ID, "status", status, "succeeded", succeeded, "failed", failed)
+}
+
+func (b *BulkAdjuster) sendItem(ctx context.Context, job *domain.BulkAdjustmentJob, i int) {
+	b.mu.Lock()
+	it := &job.Items[i]
+	it.Attempts++
+	input := it.Request()
+	b.mu.Unlock()
+
+	err := b.send(ctx, input)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job.Pending--
+	if err != nil {
+		it.Status, it.Error = domain.BulkItemFailed, err.Error()
+		job.Failed++
+		slog.WarnContext(ctx, "bulk adjustment item failed", "job_id", job.ID, "account_id", it.AccountID, "transaction_id", it.TransactionID, "err", err)
+		return
+	}
+	it.Status = domain.BulkItemSucceeded
+	job.Succeeded++
+}
+
+// expire discards finished jobs past their TTL.
+func (b *BulkAdjuster) expire(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, j := range b.jobs {
+		if j.ExpiresAt != nil && now.After(*j.ExpiresAt) {
+			delete(b.jobs, id)
+		}
+	}
+}
+
+// bulkSnapshot copies job so it can leave b.mu; the caller holds b.mu.
+func bulkSnapshot(job *domain.BulkAdjustmentJob) domain.BulkAdjustmentJob {
+	out := *job
+	out.Items = append([]domain.BulkAdjustmentItem(nil), job.Items...)
+	return out
+}
+
+func allIndexes(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+// WithBulkAdjustments sends the adjustments of bulk jobs through this
+// service, and lets it preview single adjustments with b.
+func WithBulkAdjustments(b *BulkAdjuster) AccountServiceOption {
+	return func(s *AccountServiceImpl) {
+		s.bulk = b
+		b.send = s.sendBulk
+	}
+}
+
+// sendBulk sends one adjustment of a bulk job, waiting for both backend
+// calls whatever the pipeline mode. Retries record the request only if no
+// earlier attempt did.
+func (s *AccountServiceImpl) sendBulk(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	input.RequestedBy = requestedBy(ctx)
+	if err := s.recordRequest(ctx, input); err != nil {
+		return err
+	}
+	return s.startPipeline(ctx, input, s.flags.Get().AdjustmentTimeout.Std())
+}
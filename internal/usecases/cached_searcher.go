@@ -0,0 +1,107 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sre/internal/domain"
+	"sre/internal/flags"
+)
+
+const maxCachedTerms = 1024
+
+var (
+	_ AccountSearcher = (*CachedAccountSearcher)(nil)
+	_ AccountStreamer = (*CachedAccountSearcher)(nil)
+)
+
+// NewCachedAccountSearcher wraps searcher with a per-term cache. Whether the
+// cache is used and its TTL are read from store on every call.
+func NewCachedAccountSearcher(searcher AccountSearcher, store *flags.Store) *CachedAccountSearcher {
+	return &CachedAccountSearcher{
+		searcher: searcher,
+		flags:    store,
+		entries:  make(map[string]cachedSearch),
+	}
+}
+
+type CachedAccountSearcher struct {
+	searcher AccountSearcher
+	flags    *flags.Store
+
+	mu      sync.Mutex
+	entries map[string]cachedSearch
+}
+
+type cachedSearch struct {
+	accounts  []domain.Account
+	fetchedAt time.Time
+}
+
+func (c *CachedAccountSearcher) SearchByTerm(ctx context.Context, term string) ([]domain.Account, error) {
+	f := c.flags.Get()
+	if !f.SearchCacheEnabled {
+		return c.searcher.SearchByTerm(ctx, term)
+	}
+	ttl := f.SearchCacheTTL.Std()
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[term]
+	c.mu.Unlock()
+	if ok && now.Sub(e.fetchedAt) < ttl {
+		return e.accounts, nil
+	}
+	accounts, err := c.searcher.SearchByTerm(ctx, term)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedTerms {
+		for k, v := range c.entries {
+			if now.Sub(v.fetchedAt) >= ttl {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) < maxCachedTerms {
+		c.entries[term] = cachedSearch{accounts: accounts, fetchedAt: now}
+	}
+	return accounts, nil
+}
+
+// StreamByTerm serves from the cache when it is enabled and streams from the
+// wrapped searcher otherwise.
+func (c *CachedAccountSearcher) StreamByTerm(ctx context.Context, term string, fn func(domain.Account) error) error {
+	if !c.flags.Get().SearchCacheEnabled {
+		return streamByTerm(ctx, c.searcher, term, fn)
+	}
+	accounts, err := c.SearchByTerm(ctx, term)
+	if err != nil {
+		return err
+	}
+	return each(accounts, fn)
+}
+
+// streamByTerm streams from searcher when it supports it, and iterates a
+// materialized result otherwise.
+func streamByTerm(ctx context.Context, searcher AccountSearcher, term string, fn func(domain.Account) error) error {
+	if st, ok := searcher.(AccountStreamer); ok {
+		return st.StreamByTerm(ctx, term, fn)
+	}
+	accounts, err := searcher.SearchByTerm(ctx, term)
+	if err != nil {
+		return err
+	}
+	return each(accounts, fn)
+}
+
+func each(accounts []domain.Account, fn func(domain.Account) error) error {
+	for _, a := range accounts {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
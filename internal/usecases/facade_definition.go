@@ -10,6 +10,12 @@ type AccountSearcher interface {
 	SearchByTerm(ctx context.Context, term string) ([]domain.Account, error)
 }
 
+// AccountStreamer yields the accounts matching a term one at a time. An error
+// returned by fn stops the stream and is returned.
+type AccountStreamer interface {
+	StreamByTerm(ctx context.Context, term string, fn func(domain.Account) error) error
+}
+
 // AdjustmentFlowProcessor starts the tariff adjustment approval flow.
 type AdjustmentFlowProcessor interface {
 	BeginFlow(ctx context.Context, input domain.TariffAdjustmentRequest, callbackURL string) error
@@ -0,0 +1,209 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"sre/internal/domain"
+)
+
+var _ ReportHistoryService = (*reportHistoryService)(nil)
+
+// ReportHistoryService keeps periodic snapshots of the default report and
+// compares them.
+type ReportHistoryService interface {
+	TakeSnapshot(ctx context.Context) (domain.ReportSnapshotInfo, error)
+	ListSnapshots(ctx context.Context) ([]domain.ReportSnapshotInfo, error)
+	// Diff compares two snapshots. An empty to means the latest snapshot and an
+	// empty from the one before to.
+	Diff(ctx context.Context, from, to string) (domain.ReportDiff, error)
+}
+
+// SnapshotRetention bounds how many snapshots are kept.
+type SnapshotRetention struct {
+	// MaxCount is the number of most recent snapshots kept; 0 means no limit.
+	MaxCount int
+	// MaxAge drops snapshots older than this; 0 means no limit.
+	MaxAge time.Duration
+}
+
+type reportHistoryService struct {
+	reports   ReportService
+	repo      ReportSnapshotRepository
+	retention SnapshotRetention
+}
+
+// NewReportHistoryService creates a ReportHistoryService.
+func NewReportHistoryService(reports ReportService, repo ReportSnapshotRepository, retention SnapshotRetention) *reportHistoryService {
+	return &reportHistoryService{reports: reports, repo: repo, retention: retention}
+}
+
+// RunSnapshots takes a snapshot every interval until ctx is done.
+func (s *reportHistoryService) RunSnapshots(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if _, err := s.TakeSnapshot(ctx); err != nil {
+			slog.ErrorContext(ctx, "report snapshot failed", "err", err)
+		}
+	}
+}
+
+func (s *reportHistoryService) TakeSnapshot(ctx context.Context) (domain.ReportSnapshotInfo, error) {
+	rep, err := s.reports.GetReport(ctx, domain.DefaultReportParams())
+	if err != nil {
+		return domain.ReportSnapshotInfo{}, err
+	}
+	now := time.Now().UTC()
+	snap := domain.ReportSnapshot{
+		ReportSnapshotInfo: domain.ReportSnapshotInfo{
+			ID:            snapshotID(now),
+			TakenAt:       now,
+			TotalAccounts: rep.TotalAccounts,
+		},
+		Report: rep,
+	}
+	if err := s.repo.Save(ctx, snap); err != nil {
+		return domain.ReportSnapshotInfo{}, err
+	}
+	slog.InfoContext(ctx, "report snapshot taken", "id", snap.ID, "total_accounts", rep.TotalAccounts)
+	if err := s.prune(ctx, now); err != nil {
+		slog.ErrorContext(ctx, "prune report snapshots failed", "err", err)
+	}
+	return snap.ReportSnapshotInfo, nil
+}
+
+// snapshotID formats t as a sortable, filename-safe ID like
+// "20261019T091500123Z" (milliseconds before the Z).
+func snapshotID(t time.Time) string {
+	return t.Format("20060102T150405") + fmt.Sprintf("%03dZ", t.Nanosecond()/int(time.Millisecond))
+}
+
+// prune deletes the snapshots beyond the retention, and those that cannot
+// be read, which would otherwise never be pruned.
+func (s *reportHistoryService) prune(ctx context.Context, now time.Time) error {
+	list, unreadable, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range unreadable {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		slog.WarnContext(ctx, "deleted unreadable report snapshot", "id", id)
+	}
+	for i, info := range list {
+		tooMany := s.retention.MaxCount > 0 && len(list)-i > s.retention.MaxCount
+		tooOld := s.retention.MaxAge > 0 && now.Sub(info.TakenAt) > s.retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := s.repo.Delete(ctx, info.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *reportHistoryService) ListSnapshots(ctx context.Context) ([]domain.ReportSnapshotInfo, error) {
+	list, _, err := s.repo.List(ctx)
+	return list, err
+}
+
+func (s *reportHistoryService) Diff(ctx context.Context, from, to string) (domain.ReportDiff, error) {
+	if from == "" || to == "" {
+		list, _, err := s.repo.List(ctx)
+		if err != nil {
+			return domain.ReportDiff{}, err
+		}
+		if to == "" {
+			if len(list) == 0 {
+				return domain.ReportDiff{}, fmt.Errorf("no report snapshots yet: %w", domain.ErrNotFound)
+			}
+			to = list[len(list)-1].ID
+		}
+		if from == "" {
+			for i := len(list) - 1; i > 0; i-- {
+				if list[i].ID == to {
+					from = list[i-1].ID
+					break
+				}
+			}
+			if from == "" {
+				return domain.ReportDiff{}, fmt.Errorf("no report snapshot before %q: %w", to, domain.ErrNotFound)
+			}
+		}
+	}
+	a, err := s.repo.Get(ctx, from)
+	if err != nil {
+		return domain.ReportDiff{}, err
+	}
+	b, err := s.repo.Get(ctx, to)
+	if err != nil {
+		return domain.ReportDiff{}, err
+	}
+	return diffReports(a, b), nil
+}
+
+func diffReports(a, b domain.ReportSnapshot) domain.ReportDiff {
+	d := domain.ReportDiff{
+		From:               a.ReportSnapshotInfo,
+		To:                 b.ReportSnapshotInfo,
+		TotalAccountsDelta: b.Report.TotalAccounts - a.Report.TotalAccounts,
+		TotalsByTypeDelta:  make(map[string]int),
+		EnteredTop:         []domain.RankedAccount{},
+		LeftTop:            []domain.RankedAccount{},
+		FeeChanges:         []domain.TopFeeChange{},
+	}
+	for t, n := range b.Report.TotalsByType {
+		if delta := n - a.Report.TotalsByType[t]; delta != 0 {
+			d.TotalsByTypeDelta[t] = delta
+		}
+	}
+	for t, n := range a.Report.TotalsByType {
+		if _, ok := b.Report.TotalsByType[t]; !ok {
+			d.TotalsByTypeDelta[t] = -n
+		}
+	}
+	oldRanks := rankByID(a.Report.Top100ByFee)
+	newRanks := rankByID(b.Report.Top100ByFee)
+	for i, acc := range b.Report.Top100ByFee {
+		old, ok := oldRanks[acc.ID]
+		if !ok {
+			d.EnteredTop = append(d.EnteredTop, domain.RankedAccount{Account: acc, Rank: i + 1})
+			continue
+		}
+		if prev := a.Report.Top100ByFee[old-1]; prev.MonthlyFee != acc.MonthlyFee {
+			d.FeeChanges = append(d.FeeChanges, domain.TopFeeChange{
+				AccountID: acc.ID,
+				Name:      acc.Name,
+				OldFee:    prev.MonthlyFee,
+				NewFee:    acc.MonthlyFee,
+				OldRank:   old,
+				NewRank:   i + 1,
+			})
+		}
+	}
+	for i, acc := range a.Report.Top100ByFee {
+		if _, ok := newRanks[acc.ID]; !ok {
+			d.LeftTop = append(d.LeftTop, domain.RankedAccount{Account: acc, Rank: i + 1})
+		}
+	}
+	return d
+}
+
+// rankByID maps account IDs to their 1-based rank in top.
+func rankByID(top []domain.Account) map[string]int {
+	out := make(map[string]int, len(top))
+	for i, a := range top {
+		out[a.ID] = i + 1
+	}
+	return out
+}
@@ -0,0 +1,399 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sre/internal/domain"
+	"sre/internal/export"
+)
+
+var _ ReportJobService = (*reportJobService)(nil)
+
+// ReportJobService runs expensive reports and catalog exports in the
+// background. Jobs are only visible to the client that submitted them.
+type ReportJobService interface {
+	Submit(ctx context.Context, owner domain.JobOwner, req domain.ReportJobRequest) (domain.ReportJob, error)
+	Get(ctx context.Context, clientID, id string) (domain.ReportJob, error)
+	// Cancel stops a queued or running job; a finished job is discarded
+	// together with its result.
+	Cancel(ctx context.Context, clientID, id string) (domain.ReportJob, error)
+	// OpenResult returns the result of a succeeded job and the file name and
+	// format it should be served with.
+	OpenResult(ctx context.Context, clientID, id string) (io.ReadSeekCloser, ReportJobResult, error)
+}
+
+// ReportJobResult describes a job's result file.
+type ReportJobResult struct {
+	Name     string
+	Format   export.Format
+	Modified time.Time
+}
+
+// ReportJobConfig sizes the job pool.
+type ReportJobConfig struct {
+	// Workers is the number of jobs run at once.
+	Workers int
+	// QueueSize bounds the jobs waiting for a worker.
+	QueueSize int
+	// MaxPerClient bounds the queued and running jobs of one client.
+	MaxPerClient int
+	// TTL is how long a finished job and its result are kept.
+	TTL time.Duration
+}
+
+type reportJobService struct {
+	search  SearchService
+	results ReportResultStore
+	cfg     ReportJobConfig
+	queue   chan *reportJob
+
+	mu   sync.Mutex
+	jobs map[string]*reportJob
+}
+
+// reportJob is a job's state; job is guarded by the service mutex.
+type reportJob struct {
+	job       domain.ReportJob
+	format    export.Format
+	tables    []string
+	processed atomic.Int64
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewReportJobService creates a ReportJobService. Jobs only start once Run
+// is called.
+func NewReportJobService(search SearchService, results ReportResultStore, cfg ReportJobConfig) *reportJobService {
+	return &reportJobService{
+		search:  search,
+		results: results,
+		cfg:     cfg,
+		queue:   make(chan *reportJob, cfg.QueueSize),
+		jobs:    make(map[string]*reportJob),
+	}
+}
+
+// Run starts the workers and expires finished jobs until ctx is done; running
+// jobs are cancelled with it.
+func (s *reportJobService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	t := time.NewTicker(max(min(s.cfg.TTL/2, time.Minute), time.Second))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case now := <-t.C:
+			s.expire(now)
+		}
+	}
+}
+
+func (s *reportJobService) Submit(ctx context.Context, owner domain.JobOwner, req domain.ReportJobRequest) (domain.ReportJob, error) {
+	j, err := s.newJob(owner, req)
+	if err != nil {
+		return domain.ReportJob{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := 0
+	for _, other := range s.jobs {
+		if other.job.Quota == owner.Quota && !other.job.Finished() {
+			active++
+		}
+	}
+	if active >= s.cfg.MaxPerClient {
+		return domain.ReportJob{}, domain.ErrTooManyJobs
+	}
+	select {
+	case s.queue <- j:
+	default:
+		return domain.ReportJob{}, domain.ErrJobQueueFull
+	}
+	s.jobs[j.job.ID] = j
+	slog.InfoContext(ctx, "report job queued", "job_id", j.job.ID, "client_id", owner.ID, "kind", j.job.Request.Kind, "format", j.format)
+	return j.job, nil
+}
+
+// newJob validates req and fills in its defaults.
+func (s *reportJobService) newJob(owner domain.JobOwner, req domain.ReportJobRequest) (*reportJob, error) {
+	if req.Kind == "" {
+		req.Kind = domain.ReportJobReport
+	}
+	if req.Kind != domain.ReportJobReport && req.Kind != domain.ReportJobCatalog {
+		return nil, fmt.Errorf("%w: kind must be %q or %q", domain.ErrInvalidReportParams, domain.ReportJobReport, domain.ReportJobCatalog)
+	}
+	format, err := export.Negotiate(req.Format, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidReportParams, err)
+	}
+	req.Format = string(format)
+	j := &reportJob{format: format}
+	if req.Kind == domain.ReportJobReport {
+		if err := req.ReportParams().Validate(); err != nil {
+			return nil, err
+		}
+		if j.tables, err = export.ReportTablesFor(format, req.Tables); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidReportParams, err)
+		}
+	}
+	j.job = domain.ReportJob{
+		ID:        uuid.NewString(),
+		ClientID:  owner.ID,
+		Quota:     owner.Quota,
+		Status:    domain.JobQueued,
+		Request:   req,
+		CreatedAt: time.Now().UTC(),
+	}
+	return j, nil
+}
+
+func (s *reportJobService) Get(ctx context.Context, clientID, id string) (domain.ReportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, err := s.lookup(clientID, id)
+	if err != nil {
+		return domain.ReportJob{}, err
+	}
+	return j.snapshot(), nil
+}
+
+func (s *reportJobService) Cancel(ctx context.Context, clientID, id string) (domain.ReportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, err := s.lookup(clientID, id)
+	if err != nil {
+		return domain.ReportJob{}, err
+	}
+	switch j.job.Status {
+	case domain.JobQueued:
+		// The worker that dequeues it skips it.
+		s.finish(j, domain.JobCancelled, "")
+	case domain.JobRunning:
+		// The worker records the cancellation when the job returns.
+		j.cancel()
+	default:
+		delete(s.jobs, id)
+		if err := s.results.Delete(ctx, resultName(j)); err != nil {
+			slog.ErrorContext(ctx, "delete report job result failed", "job_id", id, "err", err)
+		}
+	}
+	slog.InfoContext(ctx, "report job cancelled", "job_id", id, "client_id", clientID, "status", j.job.Status)
+	return j.snapshot(), nil
+}
+
+func (s *reportJobService) OpenResult(ctx context.Context, clientID, id string) (io.ReadSeekCloser, ReportJobResult, error) {
+	s.mu.Lock()
+	j, err := s.lookup(clientID, id)
+	if err == nil && j.job.Status != domain.JobSucceeded {
+		err = fmt.Errorf("report job is %s: %w", j.job.Status, domain.ErrJobNotReady)
+	}
+	var res ReportJobResult
+	if err == nil {
+		res = ReportJobResult{
+			Name:     fmt.Sprintf("%s-%s.%s", j.job.Request.Kind, j.job.FinishedAt.Format("20060102T150405Z"), j.format),
+			Format:   j.format,
+			Modified: *j.job.FinishedAt,
+		}
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, res, err
+	}
+	f, err := s.results.Open(ctx, resultName(j))
+	return f, res, err
+}
+
+// lookup finds a job of clientID; the caller holds s.mu.
+func (s *reportJobService) lookup(clientID, id string) (*reportJob, error) {
+	j, ok := s.jobs[id]
+	if !ok || j.job.ClientID != clientID {
+		return nil, fmt.Errorf("report job %q: %w", id, domain.ErrNotFound)
+	}
+	return j, nil
+}
+
+// snapshot returns the job with its live progress; the caller holds s.mu.
+func (j *reportJob) snapshot() domain.ReportJob {
+	out := j.job
+	out.ProcessedAccounts = int(j.processed.Load())
+	return out
+}
+
+// finish moves j to a final status; the caller holds s.mu.
+func (s *reportJobService) finish(j *reportJob, status, errMsg string) {
+	now := time.Now().UTC()
+	expires := now.Add(s.cfg.TTL)
+	j.job.Status = status
+	j.job.Error = errMsg
+	j.job.FinishedAt = &now
+	j.job.ExpiresAt = &expires
+}
+
+func (s *reportJobService) work(ctx context.Context) {
+	for {
+		var j *reportJob
+		select {
+		case <-ctx.Done():
+			return
+		case j = <-s.queue:
+		}
+		s.mu.Lock()
+		if j.job.Status != domain.JobQueued {
+			s.mu.Unlock()
+			continue
+		}
+		now := time.Now().UTC()
+		j.job.Status = domain.JobRunning
+		j.job.StartedAt = &now
+		j.ctx, j.cancel = context.WithCancel(ctx)
+		s.mu.Unlock()
+
+		err := s.run(j)
+		j.cancel()
+
+		s.mu.Lock()
+		switch {
+		case err == nil:
+			s.finish(j, domain.JobSucceeded, "")
+		case errors.Is(err, context.Canceled):
+			s.finish(j, domain.JobCancelled, "")
+		default:
+			s.finish(j, domain.JobFailed, err.Error())
+		}
+		status := j.job.Status
+		s.mu.Unlock()
+		if err != nil {
+			if derr := s.results.Delete(ctx, resultName(j)); derr != nil {
+				slog.ErrorContext(ctx, "delete report job result failed", "job_id", j.job.ID, "err", derr)
+			}
+		}
+		slog.InfoContext(ctx, "report job finished", "job_id", j.job.ID, "status", status,
+			"processed_accounts", j.processed.Load(), "err", err)
+	}
+}
+
+// run writes j's result.
+func (s *reportJobService) run(j *reportJob) (err error) {
+	out, err := s.results.Create(j.ctx, resultName(j))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	search := progressSearch{SearchService: s.search, processed: &j.processed}
+	req := j.job.Request
+	if req.Kind == domain.ReportJobCatalog {
+		return writeCatalog(j.ctx, search, out, j.format, export.Options{Locale: req.Locale})
+	}
+	rep, err := NewReportService(search).GetReport(j.ctx, req.ReportParams())
+	if err != nil {
+		return err
+	}
+	if j.format == export.FormatJSON {
+		return json.NewEncoder(out).Encode(rep)
+	}
+	w, err := export.NewWriter(j.format, out, export.Options{Locale: req.Locale})
+	if err != nil {
+		return err
+	}
+	if err := export.WriteReport(w, rep, j.tables); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// writeCatalog streams every account to out, as a JSON array or export rows.
+func writeCatalog(ctx context.Context, search SearchService, out io.Writer, f export.Format, opts export.Options) error {
+	if f == export.FormatJSON {
+		enc := json.NewEncoder(out)
+		sep := "["
+		err := search.StreamAccountsByTerm(ctx, "", func(a domain.Account) error {
+			if _, err := io.WriteString(out, sep); err != nil {
+				return err
+			}
+			sep = ","
+			return enc.Encode(a)
+		})
+		if err != nil {
+			return err
+		}
+		if sep == "[" {
+			_, err = io.WriteString(out, "[]\n")
+		} else {
+			_, err = io.WriteString(out, "]\n")
+		}
+		return err
+	}
+	w, err := export.NewWriter(f, out, opts)
+	if err != nil {
+		return err
+	}
+	if err := w.Begin(export.AccountsTable); err != nil {
+		return err
+	}
+	err = search.StreamAccountsByTerm(ctx, "", func(a domain.Account) error {
+		return w.Row(a.ID, a.Name, a.Type, a.MonthlyFee)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// expire discards finished jobs past their TTL and their results.
+func (s *reportJobService) expire(now time.Time) {
+	s.mu.Lock()
+	var expired []*reportJob
+	for id, j := range s.jobs {
+		if j.job.ExpiresAt != nil && now.After(*j.job.ExpiresAt) {
+			delete(s.jobs, id)
+			expired = append(expired, j)
+		}
+	}
+	s.mu.Unlock()
+	for _, j := range expired {
+		if err := s.results.Delete(context.Background(), resultName(j)); err != nil {
+			slog.Error("delete expired report job result failed", "job_id", j.job.ID, "err", err)
+		}
+	}
+}
+
+func resultName(j *reportJob) string {
+	return j.job.ID + "." + strings.ToLower(string(j.format))
+}
+
+// progressSearch counts the accounts streamed through it.
+type progressSearch struct {
+	SearchService
+	processed *atomic.Int64
+}
+
+func (p progressSearch) StreamAccountsByTerm(ctx context.Context, term string, fn func(domain.Account) error) error {
+	return p.SearchService.StreamAccountsByTerm(ctx, term, func(a domain.Account) error {
+		p.processed.Add(1)
+		return fn(a)
+	})
+}
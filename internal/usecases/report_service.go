@@ -1,9 +1,12 @@
 package usecases
 
 import (
-	"cmp"
+	"container/heap"
 	"context"
+	"fmt"
+	"math"
 	"slices"
+	"sort"
 
 	"sre/internal/domain"
 )
@@ -12,7 +15,7 @@ var _ ReportService = (*reportService)(nil)
 
 // ReportService builds fintech summary reports (totals by type, top by fee).
 type ReportService interface {
-	GetReport(ctx context.Context) (domain.Report, error)
+	GetReport(ctx context.Context, params domain.ReportParams) (domain.Report, error)
 }
 
 type reportService struct {
@@ -24,39 +27,178 @@ func NewReportService(searchService SearchService) *reportService {
 	return &reportService{searchService: searchService}
 }
 
-func (s *reportService) GetReport(ctx context.Context) (domain.Report, error) {
-	accounts, err := s.searchService.SearchAccountsByTerm(ctx, "")
+// GetReport streams the catalog once, aggregating counts, the fee ranking,
+// group statistics and the histogram as it goes instead of materializing it.
+func (s *reportService) GetReport(ctx context.Context, params domain.ReportParams) (domain.Report, error) {
+	if err := params.Validate(); err != nil {
+		return domain.Report{}, err
+	}
+	agg := newReportAggregator(params)
+	err := s.searchService.StreamAccountsByTerm(ctx, "", func(a domain.Account) error {
+		agg.add(a)
+		return nil
+	})
 	if err != nil {
 		return domain.Report{}, err
 	}
-	return domain.Report{
-		TotalAccounts: s.totalAccounts(accounts),
-		TotalsByType:  s.totalsByType(accounts),
-		Top100ByFee:   s.top100ByFee(accounts),
-	}, nil
+	return agg.report()
 }
 
-func (s *reportService) totalAccounts(accounts []domain.Account) int {
-	return len(accounts)
+// reportAggregator accumulates a Report from a stream of accounts.
+type reportAggregator struct {
+	params       domain.ReportParams
+	total        int
+	totalsByType map[string]int
+	top100       *topByFee
+	top          *topByFee
+	groupFees    map[string][]float64
+	histogram    map[int]int
 }
 
-func (s *reportService) totalsByType(accounts []domain.Account) map[string]int {
-	out := make(map[string]int)
-	for _, a := range accounts {
-		out[a.Type]++
+func newReportAggregator(params domain.ReportParams) *reportAggregator {
+	agg := &reportAggregator{
+		params:       params,
+		totalsByType: make(map[string]int),
+		top100:       newTopByFee(domain.DefaultReportTop, false),
 	}
-	return out
+	if !params.IsDefaultRanking() {
+		agg.top = newTopByFee(params.Top, params.Order == domain.OrderAsc)
+	}
+	if params.GroupBy != "" {
+		agg.groupFees = make(map[string][]float64)
+	}
+	if params.HistogramWidth > 0 {
+		agg.histogram = make(map[int]int)
+	}
+	return agg
+}
+
+func (g *reportAggregator) add(a domain.Account) {
+	if g.params.Type != "" && a.Type != g.params.Type {
+		return
+	}
+	g.total++
+	g.totalsByType[a.Type]++
+	g.top100.offer(a)
+	if g.top != nil {
+		g.top.offer(a)
+	}
+	if g.groupFees != nil {
+		g.groupFees[a.Type] = append(g.groupFees[a.Type], a.MonthlyFee)
+	}
+	if g.histogram != nil {
+		g.histogram[int(math.Floor(a.MonthlyFee/g.params.HistogramWidth))]++
+	}
+}
+
+func (g *reportAggregator) report() (domain.Report, error) {
+	rep := domain.Report{
+		TotalAccounts: g.total,
+		TotalsByType:  g.totalsByType,
+		Top100ByFee:   g.top100.sorted(),
+	}
+	if g.top != nil {
+		rep.Top = &domain.TopAccounts{Limit: g.params.Top, Order: g.params.Order, Accounts: g.top.sorted()}
+	}
+	if g.groupFees != nil {
+		rep.Groups = make(map[string]domain.FeeStats, len(g.groupFees))
+		for k, fees := range g.groupFees {
+			rep.Groups[k] = feeStats(fees)
+		}
+	}
+	if g.histogram != nil {
+		if len(g.histogram) > domain.MaxHistogramBuckets {
+			return domain.Report{}, fmt.Errorf("%w: histogram would have %d buckets, max is %d; use a wider bucket",
+				domain.ErrInvalidReportParams, len(g.histogram), domain.MaxHistogramBuckets)
+		}
+		w := g.params.HistogramWidth
+		for b, n := range g.histogram {
+			rep.Histogram = append(rep.Histogram, domain.HistogramBucket{From: float64(b) * w, To: float64(b+1) * w, Count: n})
+		}
+		sort.Slice(rep.Histogram, func(i, j int) bool { return rep.Histogram[i].From < rep.Histogram[j].From })
+	}
+	return rep, nil
 }
 
-func (s *reportService) top100ByFee(accounts []domain.Account) []domain.Account {
-	cp := make([]domain.Account, len(accounts))
-	copy(cp, accounts)
-	slices.SortFunc(cp, func(i, j domain.Account) int {
-		return cmp.Compare(i.MonthlyFee, j.MonthlyFee)
+// feeStats computes the aggregates of fees; it sorts fees in place.
+func feeStats(fees []float64) domain.FeeStats {
+	sort.Float64s(fees)
+	st := domain.FeeStats{Count: len(fees), Min: fees[0], Max: fees[len(fees)-1]}
+	for _, f := range fees {
+		st.Sum += f
+	}
+	st.Avg = st.Sum / float64(len(fees))
+	st.Median = percentile(fees, 0.5)
+	st.P90 = percentile(fees, 0.9)
+	return st
+}
+
+// percentile interpolates linearly between the closest ranks of sorted.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// topByFee keeps the n highest-fee (or, ascending, lowest-fee) accounts seen
+// so far in a heap whose root is the worst kept one, so each offer costs
+// O(log n) and memory stays O(n).
+type topByFee struct {
+	n    int
+	heap feeHeap
+}
+
+func newTopByFee(n int, ascending bool) *topByFee {
+	return &topByFee{n: n, heap: feeHeap{ascending: ascending, items: make([]domain.Account, 0, n)}}
+}
+
+func (t *topByFee) offer(a domain.Account) {
+	if len(t.heap.items) < t.n {
+		heap.Push(&t.heap, a)
+		return
+	}
+	if t.heap.better(a, t.heap.items[0]) {
+		t.heap.items[0] = a
+		heap.Fix(&t.heap, 0)
+	}
+}
+
+// sorted returns the kept accounts, best first.
+func (t *topByFee) sorted() []domain.Account {
+	out := slices.Clone(t.heap.items)
+	slices.SortStableFunc(out, func(i, j domain.Account) int {
+		switch {
+		case t.heap.better(i, j):
+			return -1
+		case t.heap.better(j, i):
+			return 1
+		}
+		return 0
 	})
-	slices.Reverse(cp)
-	if len(cp) > 100 {
-		return cp[:100]
+	return out
+}
+
+// feeHeap is a heap with the worst-ranked account at the root.
+type feeHeap struct {
+	ascending bool
+	items     []domain.Account
+}
+
+// better reports whether a ranks ahead of b.
+func (h feeHeap) better(a, b domain.Account) bool {
+	if h.ascending {
+		return a.MonthlyFee < b.MonthlyFee
 	}
-	return cp
+	return a.MonthlyFee > b.MonthlyFee
+}
+
+func (h feeHeap) Len() int            { return len(h.items) }
+func (h feeHeap) Less(i, j int) bool  { return h.better(h.items[j], h.items[i]) }
+func (h feeHeap) Swap(i, j int)       { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *feeHeap) Push(x interface{}) { h.items = append(h.items, x.(domain.Account)) }
+func (h *feeHeap) Pop() interface{} {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
 }
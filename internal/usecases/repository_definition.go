@@ -2,6 +2,7 @@ package usecases
 
 import (
 	"context"
+	"io"
 	"sre/internal/domain"
 )
 
@@ -17,3 +18,89 @@ type TariffAdjustmentRepository interface {
 	GetLastByAccount(ctx context.Context, acc domain.Account) (*domain.TariffAdjustmentRequest, error)
 	AllByAccount(ctx context.Context, acc domain.Account) ([]domain.TariffAdjustmentRequest, error)
 }
+
+// ReportSnapshotRepository persists report snapshots locally.
+type ReportSnapshotRepository interface {
+	Save(ctx context.Context, snap domain.ReportSnapshot) error
+	// List returns every snapshot, oldest first, and the IDs of those that
+	// cannot be read.
+	List(ctx context.Context) (list []domain.ReportSnapshotInfo, unreadable []string, err error)
+	// Get returns domain.ErrNotFound when id does not exist.
+	Get(ctx context.Context, id string) (domain.ReportSnapshot, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// ReportResultStore keeps the files produced by report jobs.
+type ReportResultStore interface {
+	// Create opens name for writing, replacing any previous content.
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	// Open returns domain.ErrNotFound when name does not exist.
+	Open(ctx context.Context, name string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// AuditLog is the append-only, hash-chained log of fee-changing operations.
+type AuditLog interface {
+	// Append assigns rec its sequence number and hashes, chaining it to the
+	// previous record, and stores it durably.
+	Append(ctx context.Context, rec domain.AuditRecord) (domain.AuditRecord, error)
+	// Query returns the matching records, oldest first.
+	Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditRecord, error)
+	// Requested reports whether the request of adjustment transactionID was
+	// recorded.
+	Requested(ctx context.Context, transactionID string) (bool, error)
+}
+
+// FeeLedger keeps the local fee history of each account.
+type FeeLedger interface {
+	// Update calls fn with the entry of transactionID, created empty if new,
+	// and stores the result.
+	Update(ctx context.Context, accountID, transactionID string, fn func(*domain.FeeHistoryEntry)) error
+	// List returns the entries of accountID in the order they were created.
+	List(ctx context.Context, accountID string) ([]domain.FeeHistoryEntry, error)
+}
+
+// ScheduledAdjustmentRepository persists scheduled tariff adjustments.
+type ScheduledAdjustmentRepository interface {
+	Save(ctx context.Context, a domain.ScheduledAdjustment) error
+	// Get returns domain.ErrNotFound when id does not exist.
+	Get(ctx context.Context, id string) (domain.ScheduledAdjustment, error)
+	// List returns every scheduled adjustment, by effective time.
+	List(ctx context.Context) ([]domain.ScheduledAdjustment, error)
+}
+
+// AdjustmentStateRepository persists the state of tariff adjustments by
+// transaction ID.
+type AdjustmentStateRepository interface {
+	Save(ctx context.Context, st domain.AdjustmentState) error
+	// Get returns domain.ErrNotFound when transactionID does not exist.
+	Get(ctx context.Context, transactionID string) (domain.AdjustmentState, error)
+}
+
+// SagaRepository persists the state of adjustment sagas.
+type SagaRepository interface {
+	Save(ctx context.Context, saga domain.Saga) error
+	// Get returns domain.ErrNotFound when id does not exist.
+	Get(ctx context.Context, id string) (domain.Saga, error)
+	// List returns every saga, oldest first.
+	List(ctx context.Context) ([]domain.Saga, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// NotificationRepository persists the decision taken on the notifications
+// of each transaction.
+type NotificationRepository interface {
+	Save(ctx context.Context, n domain.ProcessedNotification) error
+	// Get returns domain.ErrNotFound when no notification of transactionID
+	// was processed.
+	Get(ctx context.Context, transactionID string) (domain.ProcessedNotification, error)
+}
+
+// FeeVersionRepository persists, per account, the adjustment whose fee the
+// account has.
+type FeeVersionRepository interface {
+	Save(ctx context.Context, v domain.FeeVersion) error
+	// Get returns domain.ErrNotFound when no adjustment of accountID was
+	// applied yet.
+	Get(ctx context.Context, accountID string) (domain.FeeVersion, error)
+}
@@ -0,0 +1,329 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"sre/internal/domain"
+)
+
+var _ SagaService = (*SagaOrchestrator)(nil)
+
+// SagaService exposes the state of adjustment sagas.
+type SagaService interface {
+	Get(ctx context.Context, id string) (domain.Saga, error)
+	// List returns the sagas with status, or every saga when status is empty.
+	List(ctx context.Context, status string) ([]domain.Saga, error)
+}
+
+// Retry policy of saga steps and compensations.
+const (
+	sagaMaxAttempts  = 3
+	sagaRetryBackoff = 500 * time.Millisecond
+)
+
+// sagaStep is a step of the adjustment saga. compensate undoes what run
+// did, or might have done when it failed; it must be idempotent.
+type sagaStep struct {
+	name       string
+	stage      int
+	run        func(ctx context.Context, input domain.TariffAdjustmentRequest) error
+	compensate func(ctx context.Context, input domain.TariffAdjustmentRequest) error
+}
+
+// NewSagaOrchestrator creates an orchestrator that keeps saga state in repo,
+// so it survives restarts. It runs nothing until an AccountService adopts it
+// with WithSagas.
+func NewSagaOrchestrator(repo SagaRepository) *SagaOrchestrator {
+	return &SagaOrchestrator{repo: repo, active: make(map[string]bool)}
+}
+
+// SagaOrchestrator runs the steps of adjustment sagas, and their
+// compensations when a step fails for good.
+type SagaOrchestrator struct {
+	repo  SagaRepository
+	steps []sagaStep
+	// reopen undoes the compensation of a saga that is about to run again.
+	reopen func(ctx context.Context, input domain.TariffAdjustmentRequest) error
+
+	// mu serializes saga updates, including those of parallel steps; active
+	// holds the sagas being run.
+	mu     sync.Mutex
+	active map[string]bool
+}
+
+// Run resumes the sagas a crash interrupted, then every interval discards
+// completed and compensated sagas older than retention, until ctx is done.
+func (o *SagaOrchestrator) Run(ctx context.Context, interval, retention time.Duration) {
+	o.resume(ctx)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			o.prune(ctx, now.Add(-retention))
+		}
+	}
+}
+
+func (o *SagaOrchestrator) Get(ctx context.Context, id string) (domain.Saga, error) {
+	return o.repo.Get(ctx, id)
+}
+
+func (o *SagaOrchestrator) List(ctx context.Context, status string) ([]domain.Saga, error) {
+	all, err := o.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []domain.Saga{}
+	for _, s := range all {
+		if status == "" || s.Status == status {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// execute runs the saga of input to its end: a completed saga returns nil
+// right away, an interrupted one resumes, and a compensated or failed one
+// starts over. It returns the step error of a saga that was compensated.
+func (o *SagaOrchestrator) execute(ctx context.Context, input domain.TariffAdjustmentRequest) error {
+	saga, restarted, err := o.claim(ctx, input)
+	if err != nil || saga == nil {
+		return err
+	}
+	defer o.release(saga.ID)
+	if restarted && o.reopen != nil {
+		if err := o.reopen(ctx, input); err != nil {
+			o.update(ctx, saga, func() { saga.Status, saga.Error = domain.SagaCompensated, err.Error() })
+			return err
+		}
+	}
+	return o.drive(ctx, saga)
+}
+
+// claim loads or creates the saga of input and marks it active.
+func (o *SagaOrchestrator) claim(ctx context.Context, input domain.TariffAdjustmentRequest) (*domain.Saga, bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	id := input.TransactionID
+	if o.active[id] {
+		return nil, false, fmt.Errorf("saga %q: %w", id, domain.ErrSagaRunning)
+	}
+	saga, err := o.repo.Get(ctx, id)
+	now := time.Now().UTC()
+	restarted := false
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		saga = domain.Saga{ID: id, Input: input, CreatedAt: now}
+		o.reset(&saga)
+	case err != nil:
+		return nil, false, err
+	case saga.Status == domain.SagaCompleted:
+		return nil, false, nil
+	case saga.Status == domain.SagaCompensated || saga.Status == domain.SagaFailed:
+		saga.Input = input
+		o.reset(&saga)
+		restarted = true
+	}
+	saga.UpdatedAt = now
+	if err := o.repo.Save(ctx, saga); err != nil {
+		return nil, false, err
+	}
+	o.active[id] = true
+	return &saga, restarted, nil
+}
+
+// reset starts a new run of saga.
+func (o *SagaOrchestrator) reset(saga *domain.Saga) {
+	saga.Status, saga.Error = domain.SagaRunning, ""
+	saga.Runs++
+	saga.Steps = saga.Steps[:0]
+	for _, st := range o.steps {
+		saga.Steps = append(saga.Steps, domain.SagaStep{Name: st.name, Stage: st.stage, Status: domain.StepPending})
+	}
+}
+
+func (o *SagaOrchestrator) release(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.active, id)
+}
+
+// drive moves a claimed saga forward, or backward once a step failed.
+func (o *SagaOrchestrator) drive(ctx context.Context, saga *domain.Saga) error {
+	var stepErr error
+	if saga.Status == domain.SagaRunning {
+		if stepErr = o.forward(ctx, saga); stepErr == nil {
+			o.update(ctx, saga, func() { saga.Status = domain.SagaCompleted })
+			return nil
+		}
+		slog.WarnContext(ctx, "saga step failed; compensating", "saga_id", saga.ID, "err", stepErr)
+		o.update(ctx, saga, func() { saga.Status, saga.Error = domain.SagaCompensating, stepErr.Error() })
+	} else {
+		stepErr = errors.New(saga.Error)
+	}
+	if err := o.backward(ctx, saga); err != nil {
+		slog.ErrorContext(ctx, "saga compensation failed", "saga_id", saga.ID, "err", err)
+		o.update(ctx, saga, func() { saga.Status, saga.Error = domain.SagaFailed, stepErr.Error()+"; "+err.Error() })
+		return errors.Join(stepErr, err)
+	}
+	o.update(ctx, saga, func() { saga.Status = domain.SagaCompensated })
+	slog.InfoContext(ctx, "saga compensated", "saga_id", saga.ID)
+	return stepErr
+}
+
+// forward runs the stages in order and the steps of a stage in parallel,
+// skipping the steps done before a crash.
+func (o *SagaOrchestrator) forward(ctx context.Context, saga *domain.Saga) error {
+	for stage := 0; ; stage++ {
+		var todo []int
+		found := false
+		for i, st := range saga.Steps {
+			if st.Stage == stage {
+				found = true
+				if st.Status != domain.StepDone {
+					todo = append(todo, i)
+				}
+			}
+		}
+		if !found {
+			return nil
+		}
+		errs := make([]error, len(todo))
+		var wg sync.WaitGroup
+		for j, i := range todo {
+			wg.Add(1)
+			go func(j, i int) {
+				defer wg.Done()
+				errs[j] = o.attempt(ctx, saga, i, o.step(saga.Steps[i].Name).run, domain.StepDone)
+			}(j, i)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+}
+
+// backward compensates every step that ran or may have run, last stage
+// first.
+func (o *SagaOrchestrator) backward(ctx context.Context, saga *domain.Saga) error {
+	for i := len(saga.Steps) - 1; i >= 0; i-- {
+		st := saga.Steps[i]
+		def := o.step(st.Name)
+		if st.Status == domain.StepPending || st.Status == domain.StepCompensated || def.compensate == nil {
+			continue
+		}
+		if err := o.attempt(ctx, saga, i, def.compensate, domain.StepCompensated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt calls fn for step i until it succeeds, moving the step to done
+// (StepDone or StepCompensated), or sagaMaxAttempts tries failed. Every
+// try is saved before it starts, so a crash leads to a retry; the backend
+// deduplicates by transaction ID.
+func (o *SagaOrchestrator) attempt(ctx context.Context, saga *domain.Saga, i int, fn func(context.Context, domain.TariffAdjustmentRequest) error, done string) error {
+	name := saga.Steps[i].Name
+	for n := 1; ; n++ {
+		o.update(ctx, saga, func() {
+			now := time.Now().UTC()
+			if st := &saga.Steps[i]; done == domain.StepDone {
+				st.Attempts++
+				st.Status, st.StartedAt = domain.StepRunning, &now
+			}
+		})
+		err := fn(ctx, saga.Input)
+		o.update(ctx, saga, func() {
+			now := time.Now().UTC()
+			st := &saga.Steps[i]
+			st.FinishedAt = &now
+			if err == nil {
+				st.Status, st.Error = done, ""
+			} else {
+				st.Error = err.Error()
+				if done == domain.StepDone {
+					st.Status = domain.StepFailed
+				}
+			}
+		})
+		if err == nil {
+			return nil
+		}
+		if n >= sagaMaxAttempts || ctx.Err() != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", name, ctx.Err())
+		case <-time.After(sagaRetryBackoff << (n - 1)):
+		}
+	}
+}
+
+// update applies fn to saga and saves it. A failed save is only logged:
+// the saga goes on, and at worst repeats idempotent steps after a crash.
+func (o *SagaOrchestrator) update(ctx context.Context, saga *domain.Saga, fn func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fn()
+	saga.UpdatedAt = time.Now().UTC()
+	if err := o.repo.Save(ctx, *saga); err != nil {
+		slog.ErrorContext(ctx, "save saga failed", "saga_id", saga.ID, "err", err)
+	}
+}
+
+func (o *SagaOrchestrator) step(name string) sagaStep {
+	for _, st := range o.steps {
+		if st.name == name {
+			return st
+		}
+	}
+	return sagaStep{name: name, run: func(context.Context, domain.TariffAdjustmentRequest) error {
+		return fmt.Errorf("unknown saga step %q", name)
+	}}
+}
+
+// resume finishes the sagas that were running or compensating when the
+// service stopped.
+func (o *SagaOrchestrator) resume(ctx context.Context) {
+	all, err := o.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "list sagas failed", "err", err)
+		return
+	}
+	for _, s := range all {
+		if s.Status != domain.SagaRunning && s.Status != domain.SagaCompensating {
+			continue
+		}
+		slog.InfoContext(ctx, "resuming saga", "saga_id", s.ID, "status", s.Status)
+		if err := o.execute(ctx, s.Input); err != nil {
+			slog.WarnContext(ctx, "resumed saga did not complete", "saga_id", s.ID, "err", err)
+		}
+	}
+}
+
+// prune deletes the final sagas last updated before cutoff.
+func (o *SagaOrchestrator) prune(ctx context.Context, cutoff time.Time) {
+	all, err := o.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "list sagas failed", "err", err)
+		return
+	}
+	for _, s := range all {
+		if s.Finished() && s.UpdatedAt.Before(cutoff) {
+			if err := o.repo.Delete(ctx, s.ID); err != nil {
+				slog.ErrorContext(ctx, "delete saga failed", "saga_id", s.ID, "err", err)
+			}
+		}
+	}
+}
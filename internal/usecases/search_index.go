@@ -0,0 +1,331 @@
+package usecases
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"sre/internal/domain"
+	"sre/internal/utils"
+)
+
+// feeBucketWidth is the width of the monthly_fee ranges the index keeps.
+const feeBucketWidth = 10.0
+
+// Match scores for a single query token; an account's score is the sum over
+// query tokens of the best match among its tokens.
+const (
+	scoreExact  = 3
+	scorePrefix = 2
+	scoreFuzzy  = 1
+)
+
+// FeeUpdateListener is notified after an account's fee was changed in the backend.
+type FeeUpdateListener interface {
+	FeeUpdated(accountID string, newFee float64)
+}
+
+var _ FeeUpdateListener = (*SearchIndex)(nil)
+
+// SearchIndex is an in-process inverted index over the account catalog: name
+// and type tokens, plus fee ranges. Tokens are lower-cased and accent-folded,
+// so "João" matches "joao". It is rebuilt from catalog snapshots and patched
+// on fee updates.
+type SearchIndex struct {
+	mu          sync.RWMutex
+	data        *indexData
+	refreshedAt time.Time
+}
+
+type indexData struct {
+	accounts   map[string]domain.Account
+	postings   map[string][]string // token -> account IDs
+	terms      []string            // sorted token dictionary
+	feeBuckets map[int][]string    // fee bucket -> account IDs
+}
+
+// NewSearchIndex creates a cold index; it serves nothing until the first Refresh.
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{}
+}
+
+// Ready reports whether the index holds a catalog snapshot.
+func (ix *SearchIndex) Ready() bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.data != nil
+}
+
+// Refresh rebuilds the index from a full catalog snapshot streamed from searcher.
+func (ix *SearchIndex) Refresh(ctx context.Context, searcher AccountSearcher) error {
+	b := newIndexBuilder()
+	if err := streamByTerm(ctx, searcher, "", func(a domain.Account) error {
+		b.add(a)
+		return nil
+	}); err != nil {
+		return err
+	}
+	data := b.build()
+	ix.mu.Lock()
+	ix.data = data
+	ix.refreshedAt = time.Now()
+	ix.mu.Unlock()
+	slog.InfoContext(ctx, "search index refreshed", "accounts", len(data.accounts), "terms", len(data.terms))
+	return nil
+}
+
+// RunRefresher refreshes the index now and then every interval until ctx is done.
+func (ix *SearchIndex) RunRefresher(ctx context.Context, searcher AccountSearcher, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := ix.Refresh(ctx, searcher); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "search index refresh failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// FeeUpdated patches the fee of an indexed account.
+func (ix *SearchIndex) FeeUpdated(accountID string, newFee float64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.data == nil {
+		return
+	}
+	a, ok := ix.data.accounts[accountID]
+	if !ok {
+		return
+	}
+	if oldB, newB := feeBucket(a.MonthlyFee), feeBucket(newFee); oldB != newB {
+		ix.data.feeBuckets[oldB] = remove(ix.data.feeBuckets[oldB], accountID)
+		ix.data.feeBuckets[newB] = append(ix.data.feeBuckets[newB], accountID)
+	}
+	a.MonthlyFee = newFee
+	ix.data.accounts[accountID] = a
+}
+
+// Search returns the accounts matching every token of term, by descending
+// relevance. A query token matches an account token exactly, as a prefix, or
+// within a small edit distance (1 for 4-7 letters, 2 for longer tokens).
+func (ix *SearchIndex) Search(term string) []domain.Account {
+	qtokens := tokenize(term)
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if len(qtokens) == 0 || ix.data == nil {
+		return []domain.Account{}
+	}
+	var scores map[string]int
+	for _, q := range qtokens {
+		best := make(map[string]int)
+		for tok, score := range ix.data.matchTerms(q) {
+			for _, id := range ix.data.postings[tok] {
+				if score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id, s := range scores {
+			if b, ok := best[id]; ok {
+				scores[id] = s + b
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	out := make([]domain.Account, 0, len(scores))
+	for id := range scores {
+		out = append(out, ix.data.accounts[id])
+	}
+	sort.Slice(out, func(i, j int) bool {
+		si, sj := scores[out[i].ID], scores[out[j].ID]
+		if si != sj {
+			return si > sj
+		}
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// FeeRange returns the accounts with min <= monthly_fee <= max.
+func (ix *SearchIndex) FeeRange(min, max float64) []domain.Account {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if ix.data == nil || min > max {
+		return nil
+	}
+	var out []domain.Account
+	for b := feeBucket(min); b <= feeBucket(max); b++ {
+		for _, id := range ix.data.feeBuckets[b] {
+			if a := ix.data.accounts[id]; a.MonthlyFee >= min && a.MonthlyFee <= max {
+				out = append(out, a)
+			}
+		}
+	}
+	return out
+}
+
+// Snapshot returns every indexed account, in no particular order.
+func (ix *SearchIndex) Snapshot() []domain.Account {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if ix.data == nil {
+		return nil
+	}
+	out := make([]domain.Account, 0, len(ix.data.accounts))
+	for _, a := range ix.data.accounts {
+		out = append(out, a)
+	}
+	return out
+}
+
+// matchTerms returns the dictionary tokens matching q with their score.
+func (d *indexData) matchTerms(q string) map[string]int {
+	out := make(map[string]int)
+	i := sort.SearchStrings(d.terms, q)
+	for ; i < len(d.terms) && strings.HasPrefix(d.terms[i], q); i++ {
+		if d.terms[i] == q {
+			out[q] = scoreExact
+		} else {
+			out[d.terms[i]] = scorePrefix
+		}
+	}
+	maxEdits := maxEditsFor(q)
+	if maxEdits == 0 {
+		return out
+	}
+	qr := []rune(q)
+	for _, t := range d.terms {
+		if _, ok := out[t]; ok {
+			continue
+		}
+		tr := []rune(t)
+		if abs(len(tr)-len(qr)) > maxEdits {
+			continue
+		}
+		if editDistance(qr, tr, maxEdits) <= maxEdits {
+			out[t] = scoreFuzzy
+		}
+	}
+	return out
+}
+
+type indexBuilder struct {
+	data *indexData
+}
+
+func newIndexBuilder() *indexBuilder {
+	return &indexBuilder{data: &indexData{
+		accounts:   make(map[string]domain.Account),
+		postings:   make(map[string][]string),
+		feeBuckets: make(map[int][]string),
+	}}
+}
+
+func (b *indexBuilder) add(a domain.Account) {
+	if _, dup := b.data.accounts[a.ID]; dup {
+		return
+	}
+	b.data.accounts[a.ID] = a
+	seen := make(map[string]bool)
+	for _, tok := range append(tokenize(a.Name), tokenize(a.Type)...) {
+		if seen[tok] {
+			continue
+		}
+		seen[tok] = true
+		b.data.postings[tok] = append(b.data.postings[tok], a.ID)
+	}
+	fb := feeBucket(a.MonthlyFee)
+	b.data.feeBuckets[fb] = append(b.data.feeBuckets[fb], a.ID)
+}
+
+func (b *indexBuilder) build() *indexData {
+	b.data.terms = make([]string, 0, len(b.data.postings))
+	for t := range b.data.postings {
+		b.data.terms = append(b.data.terms, t)
+	}
+	sort.Strings(b.data.terms)
+	return b.data
+}
+
+// tokenize lower-cases and accent-folds s and splits it on anything that is
+// not a letter or digit.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(utils.Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func maxEditsFor(q string) int {
+	switch n := len([]rune(q)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the Levenshtein distance between a and b, giving up with
+// max+1 as soon as it must exceed max.
+func editDistance(a, b []rune, max int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func feeBucket(fee float64) int {
+	return int(math.Floor(fee / feeBucketWidth))
+}
+
+func remove(ids []string, id string) []string {
+	for i, v := range ids {
+		if v == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
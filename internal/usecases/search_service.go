@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"sre/internal/domain"
+	"sre/internal/query"
 
 	"log/slog"
 )
@@ -13,18 +14,40 @@ var _ SearchService = (*SearchServiceImpl)(nil)
 // SearchService searches accounts by term.
 type SearchService interface {
 	SearchAccountsByTerm(ctx context.Context, term string) ([]domain.Account, error)
+	// StreamAccountsByTerm calls fn for each matching account without
+	// materializing the result.
+	StreamAccountsByTerm(ctx context.Context, term string, fn func(domain.Account) error) error
+	// QueryAccounts returns the catalog accounts matching expr, ordered by sort.
+	QueryAccounts(ctx context.Context, expr query.Expr, sort []query.SortKey) ([]domain.Account, error)
 }
 
 // NewSearchService creates a SearchService.
-func NewSearchService(searcher AccountSearcher) *SearchServiceImpl {
-	return &SearchServiceImpl{searcher: searcher}
+func NewSearchService(searcher AccountSearcher, opts ...SearchServiceOption) *SearchServiceImpl {
+	s := &SearchServiceImpl{searcher: searcher}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// SearchServiceOption configures optional SearchServiceImpl dependencies.
+type SearchServiceOption func(*SearchServiceImpl)
+
+// WithSearchIndex answers non-empty term searches from index once it is
+// warm; the backend is only queried while the index is cold.
+func WithSearchIndex(index *SearchIndex) SearchServiceOption {
+	return func(s *SearchServiceImpl) { s.index = index }
 }
 
 type SearchServiceImpl struct {
 	searcher AccountSearcher
+	index    *SearchIndex
 }
 
 func (s *SearchServiceImpl) SearchAccountsByTerm(ctx context.Context, term string) ([]domain.Account, error) {
+	if term != "" && s.index != nil && s.index.Ready() {
+		return s.index.Search(term), nil
+	}
 	accounts, err := s.searcher.SearchByTerm(ctx, term)
 	if err != nil {
 		slog.ErrorContext(ctx, "search by term failed", "term", term, "err", err)
@@ -32,3 +55,39 @@ func (s *SearchServiceImpl) SearchAccountsByTerm(ctx context.Context, term strin
 	}
 	return accounts, nil
 }
+
+func (s *SearchServiceImpl) StreamAccountsByTerm(ctx context.Context, term string, fn func(domain.Account) error) error {
+	if err := streamByTerm(ctx, s.searcher, term, fn); err != nil {
+		slog.ErrorContext(ctx, "stream by term failed", "term", term, "err", err)
+		return err
+	}
+	return nil
+}
+
+// QueryAccounts evaluates expr over the indexed catalog, or over a catalog
+// streamed from the backend while the index is cold.
+func (s *SearchServiceImpl) QueryAccounts(ctx context.Context, expr query.Expr, sort []query.SortKey) ([]domain.Account, error) {
+	out := []domain.Account{}
+	if s.index != nil && s.index.Ready() {
+		for _, a := range s.index.Snapshot() {
+			if expr.Match(a) {
+				out = append(out, a)
+			}
+		}
+	} else {
+		err := s.StreamAccountsByTerm(ctx, "", func(a domain.Account) error {
+			if expr.Match(a) {
+				out = append(out, a)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(sort) == 0 {
+		sort = []query.SortKey{{Field: query.FieldID}}
+	}
+	query.Sort(out, sort)
+	return out, nil
+}
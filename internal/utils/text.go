@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fold lower-cases s and strips the diacritics used in Portuguese, so "João"
+// and "joao" compare equal.
+func Fold(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if f, ok := accentFold[r]; ok {
+			return f
+		}
+		return r
+	}, s)
+}
+
+var accentFold = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}